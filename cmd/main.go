@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"url_shortener/internal/config"
+	"url_shortener/internal/geoip"
+	"url_shortener/internal/grpc_server"
 	"url_shortener/internal/http_server/controllers"
+	"url_shortener/internal/http_server/middleware"
 	"url_shortener/internal/http_server/routers"
+	"url_shortener/internal/logger"
+	"url_shortener/internal/reputation"
 	"url_shortener/internal/services"
 	"url_shortener/internal/storage/postgres"
+	"url_shortener/internal/tracing"
+	"url_shortener/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -25,15 +38,131 @@ func main() {
 	log := createLogger(cfg.Env)
 	log.Info("application has been started")
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("fail during setting up tracing", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		os.Exit(1)
+	}
+
 	storage, err := postgres.New(cfg)
 	if err != nil {
 		log.Error("fail during loading the storage", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		os.Exit(1)
 	}
 
-	r := setupRouter(*storage, log, *cfg)
-	if err := r.Run(cfg.Addres); err != nil {
-		log.Error("Failed to start server:", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	var geo geoip.Resolver
+	if cfg.GeoIP.DatabasePath != "" {
+		reader, err := geoip.Open(cfg.GeoIP.DatabasePath)
+		if err != nil {
+			log.Error("fail during loading the geoip database", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			os.Exit(1)
+		}
+		defer reader.Close()
+		geo = reader
+	}
+
+	aliases, err := services.NewAliasPolicy(cfg.Aliases)
+	if err != nil {
+		log.Error("fail during loading the alias policy", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		os.Exit(1)
+	}
+	destinations, err := services.NewDestinationPolicy(cfg.Destinations)
+	if err != nil {
+		log.Error("fail during loading the destination policy", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		os.Exit(1)
+	}
+
+	var checker reputation.Checker
+	if len(cfg.Reputation.Lists) > 0 {
+		chain, err := reputation.New(cfg.Reputation, log)
+		if err != nil {
+			log.Error("fail during loading the reputation lists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			os.Exit(1)
+		}
+		checker = chain
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the HTTP and gRPC servers share the service, and with it the policies
+	// and the reputation lists
+	urlService := services.NewURLService(storage, geo, aliases, destinations, checker, *cfg, log)
+
+	router, err := setupRouter(*storage, urlService, log, *cfg)
+	if err != nil {
+		log.Error("fail during setting up the router", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		os.Exit(1)
+	}
+
+	srv := &http.Server{
+		Addr:        cfg.Addres,
+		Handler:     router,
+		ReadTimeout: cfg.Timeout,
+		IdleTimeout: cfg.IdleTimeout,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Failed to start server:", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			stop()
+		}
+	}()
+
+	grpcServer := grpc_server.New(urlService, *cfg, cfg.PublicBaseURL+routers.RedirectPath(*cfg), log)
+	lis, err := net.Listen("tcp", cfg.GrpcServer.Addres)
+	if err != nil {
+		log.Error("failed to listen for grpc", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		os.Exit(1)
+	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Error("failed to start grpc server", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			stop()
+		}
+	}()
+
+	worker := webhooks.NewWorker(storage, cfg.Webhooks, log)
+	workerDone := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(workerDone)
+	}()
+
+	scannerDone := make(chan struct{})
+	go func() {
+		if checker != nil && cfg.Reputation.RecheckInterval > 0 {
+			reputation.NewScanner(storage, checker, cfg.Reputation, log).Run(ctx)
+		}
+		close(scannerDone)
+	}()
+
+	<-ctx.Done()
+	log.Info("shutting down")
+	<-workerDone
+	<-scannerDone
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.GrpcServer.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to shut down server", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+
+	// GracefulStop waits for the running calls, which are cut short once the
+	// shutdown timeout has passed
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("failed to flush spans", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 	}
 }
 
@@ -42,19 +171,35 @@ func createLogger(env string) *slog.Logger {
 
 	switch env {
 	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		log = slog.New(logger.NewHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})))
 	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		log = slog.New(logger.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})))
 	}
 
 	return log
 }
 
-func setupRouter(storage postgres.Storage, log *slog.Logger, cfg config.Config) *gin.Engine {
-	r := gin.Default()
-	urlService := services.NewURLService(&storage, log)
-	urlController := controllers.NewURLController(urlService, log)
+func setupRouter(storage postgres.Storage, urlService services.UrlService, log *slog.Logger, cfg config.Config) (*gin.Engine, error) {
+	r := gin.New()
+	if err := routers.SetupTrustedProxies(r, cfg); err != nil {
+		return nil, err
+	}
+	// aliases of links below prefix links hold slashes, escaped as %2F in
+	// the management routes
+	r.UseRawPath = true
+	r.Use(middleware.RequestID(), middleware.Tracing(), middleware.AccessLog(log), gin.Recovery())
+	urlController := controllers.NewURLController(urlService, cfg.PublicBaseURL+routers.RedirectPath(cfg), log)
+	domainService := services.NewDomainService(&storage, cfg, log)
+	domainController := controllers.NewDomainController(domainService, log)
+	webhookService := services.NewWebhookService(&storage, log)
+	webhookController := controllers.NewWebhookController(webhookService, log)
+	auditService := services.NewAuditService(&storage, log)
+	auditController := controllers.NewAuditController(auditService, log)
 
 	routers.SetupURLRoutes(r, urlController, cfg)
-	return r
+	routers.SetupDomainRoutes(r, domainController, cfg)
+	routers.SetupWebhookRoutes(r, webhookController, cfg)
+	routers.SetupAuditRoutes(r, auditController, cfg)
+	routers.SetupDocsRoutes(r)
+	return r, nil
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"net/url"
 	"os"
 	"time"
 
@@ -12,6 +13,13 @@ type Config struct {
 	Env             string `yaml:"env" env-default:"local"`
 	StoragePath     string `yaml:"storage_path" env-required:"true"`
 	HttpServer      `yaml:"http_server"`
+	GrpcServer      GrpcServer   `yaml:"grpc_server"`
+	Webhooks        Webhooks     `yaml:"webhooks"`
+	Tracing         Tracing      `yaml:"tracing"`
+	GeoIP           GeoIP        `yaml:"geoip"`
+	Aliases         Aliases      `yaml:"aliases"`
+	Destinations    Destinations `yaml:"destinations"`
+	Reputation      Reputation   `yaml:"reputation"`
 	PostgresConnect `yaml:"postgres_storage"`
 }
 
@@ -21,6 +29,137 @@ type HttpServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 	User        string        `yaml:"user" env-required:"true"`
 	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// PublicBaseURL is the scheme and host short links are served from.
+	PublicBaseURL string `yaml:"public_base_url" env-default:"http://localhost:8080"`
+	// DefaultDomain serves the links that are not bound to a custom domain.
+	// It defaults to the host of PublicBaseURL.
+	DefaultDomain string `yaml:"default_domain"`
+	// RootRedirects serves short links at "/:alias" instead of "/url/:alias"
+	// and moves the management API under "/api/v1".
+	RootRedirects bool `yaml:"root_redirects" env-default:"false"`
+	// TrustedProxies lists the addresses and CIDR ranges of the proxies whose
+	// X-Forwarded-For headers are trusted for the client IP.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// GrpcServer serves the link management API over gRPC with the credentials
+// of the HTTP server.
+type GrpcServer struct {
+	Addres string `yaml:"addres" env-default:"localhost:9090"`
+	// ShutdownTimeout is how long in-flight calls may take to finish on
+	// shutdown before they are cancelled.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+}
+
+// Webhooks configures the delivery of link events to subscriptions.
+type Webhooks struct {
+	// Interval is how often the worker looks for events to deliver.
+	Interval    time.Duration `yaml:"interval" env-default:"5s"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
+	BatchSize   int           `yaml:"batch_size" env-default:"50"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"8"`
+	// The retry after the n-th failed attempt waits BackoffBase * 2^(n-1),
+	// at most BackoffMax.
+	BackoffBase time.Duration `yaml:"backoff_base" env-default:"30s"`
+	BackoffMax  time.Duration `yaml:"backoff_max" env-default:"1h"`
+	// ClickThresholds are the visit counts that trigger a link.threshold event.
+	ClickThresholds []int `yaml:"click_thresholds" env-default:"100,1000,10000"`
+}
+
+// Tracing configures the export of OpenTelemetry spans.
+type Tracing struct {
+	// Exporter is "none", "stdout" or "otlp". With "none" the incoming trace
+	// context is still propagated but no spans are recorded.
+	Exporter string `yaml:"exporter" env-default:"none"`
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Endpoint    string `yaml:"endpoint" env-default:"localhost:4317"`
+	Insecure    bool   `yaml:"insecure" env-default:"false"`
+	ServiceName string `yaml:"service_name" env-default:"url_shortener"`
+	// SampleRatio is the share of new traces that are recorded. Requests that
+	// are part of a sampled trace are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// GeoIP locates visitors for the country overrides of links.
+type GeoIP struct {
+	// DatabasePath is a MaxMind-format country or city database. Without it
+	// links always redirect to their default destination.
+	DatabasePath string `yaml:"database_path"`
+}
+
+// Aliases is the policy the custom aliases of new links must follow. Empty
+// settings fall back to the defaults of the service.
+type Aliases struct {
+	// Characters are the characters allowed in aliases besides the "/"
+	// between the segments of prefix aliases.
+	Characters string `yaml:"characters" env-default:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_."`
+	MinLength  int    `yaml:"min_length" env-default:"1"`
+	MaxLength  int    `yaml:"max_length" env-default:"64"`
+	// FoldCase stores aliases lowercased and serves them regardless of the
+	// case of the visited path. Links saved with uppercase letters before it
+	// was turned on are no longer reachable.
+	FoldCase bool `yaml:"fold_case" env-default:"false"`
+	// Reserved are refused as aliases on top of the names of the routes.
+	Reserved []string `yaml:"reserved"`
+	// BlocklistPath is a file of words aliases must not contain, one per
+	// line. Lines starting with "#" are comments.
+	BlocklistPath string `yaml:"blocklist_path"`
+	// AllowConfusables turns off the detection of aliases spelling reserved
+	// or blocked words with look-alike characters, such as "p0rn" or a
+	// Cyrillic "а" in "аpi", and of aliases mixing scripts.
+	AllowConfusables bool `yaml:"allow_confusables" env-default:"false"`
+}
+
+// Destinations restricts where links can go. An entry is a host such as
+// "example.com", a wildcard such as "*.example.com" matching its subdomains,
+// or an IP address or CIDR such as "203.0.113.0/24" matching IP literals.
+type Destinations struct {
+	// Allow, when set, is the only hosts destinations can be on.
+	Allow []string `yaml:"allow"`
+	// Deny are hosts destinations cannot be on, even if they are allowed.
+	Deny []string `yaml:"deny"`
+	// AllowPrivate accepts destinations on loopback, private and link-local
+	// addresses, on names such as localhost or *.internal and on names that
+	// resolve to such addresses, which would turn short links into redirects
+	// into the network of their visitors.
+	AllowPrivate bool `yaml:"allow_private" env-default:"false"`
+}
+
+// Reputation checks the destinations of links against lists of malicious
+// URLs, when links are created or updated and again every RecheckInterval.
+type Reputation struct {
+	// Lists are checked in order, the first one listing a destination
+	// refuses it.
+	Lists []ReputationList `yaml:"lists"`
+	// RecheckInterval is how often the stored links are checked again, the
+	// links whose destination has been listed since are blocked. Zero
+	// disables the rechecks.
+	RecheckInterval time.Duration `yaml:"recheck_interval" env-default:"6h"`
+	// BatchSize is the number of links read at a time by the rechecks.
+	BatchSize int `yaml:"batch_size" env-default:"100"`
+}
+
+// ReputationList is a local list of hash prefixes in the format of the Safe
+// Browsing lists: the SHA-256 hashes of the host suffix and path prefix
+// expressions of the listed URLs, cut to their first 4 to 32 bytes.
+type ReputationList struct {
+	// Name is reported as the source of the verdicts, the file name when
+	// empty.
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	// Format is "hex" for a hex-encoded prefix per line, lines starting with
+	// "#" being comments, or "raw" for the concatenated prefixes of
+	// PrefixSize bytes of the rawHashes of a Safe Browsing update.
+	Format     string `yaml:"format"`
+	PrefixSize int    `yaml:"prefix_size"`
+	// Threat is the kind of the listed URLs, such as MALWARE or
+	// SOCIAL_ENGINEERING.
+	Threat string `yaml:"threat"`
+	// Timeout bounds a check against the list, zero disables it.
+	Timeout time.Duration `yaml:"timeout"`
+	// FailOpen accepts destinations when the list cannot be checked instead
+	// of refusing them.
+	FailOpen bool `yaml:"fail_open"`
 }
 
 type PostgresConnect struct {
@@ -29,6 +168,8 @@ type PostgresConnect struct {
 	User         string `yaml:"user" env-default:"postgres"`
 	Password     string `yaml:"password"  env-required:"true"`
 	DatabaseName string `yaml:"dbname"  env-required:"true"`
+	// QueryTimeout bounds every storage call, zero disables it.
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"3s"`
 }
 
 func MustLoad() *Config {
@@ -47,5 +188,13 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	if cfg.DefaultDomain == "" {
+		publicURL, err := url.Parse(cfg.PublicBaseURL)
+		if err != nil {
+			log.Fatalf("invalid public base url: %s", err)
+		}
+		cfg.DefaultDomain = publicURL.Hostname()
+	}
+
 	return &cfg
 }
@@ -0,0 +1,65 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrInvalidIP = errors.New("invalid ip address")
+
+// Resolver maps client addresses to the country they are located in.
+type Resolver interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country of ip, or
+	// an empty string if the database does not know the address.
+	Country(ip string) (string, error)
+}
+
+// Reader looks up countries in a local MaxMind-format database, such as
+// GeoLite2-Country or GeoIP2-City.
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+var _ Resolver = (*Reader)(nil) // check if Reader implements Resolver interface
+
+// countryRecord is the part of the country and city records that is read.
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// Open memory maps the database file at path.
+func Open(path string) (*Reader, error) {
+	const fn = "geoip.geoip.Open"
+
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &Reader{db: db}, nil
+}
+
+func (r *Reader) Country(ip string) (string, error) {
+	const fn = "geoip.geoip.Country"
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", fmt.Errorf("%s: %w: %q", fn, ErrInvalidIP, ip)
+	}
+
+	var record countryRecord
+	if err := r.db.Lookup(addr, &record); err != nil {
+		return "", fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return record.Country.ISOCode, nil
+}
+
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,46 @@
+package geoip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testdata/GeoLite2-Country-Test.mmdb maps 81.2.69.0/24 to GB, 89.160.20.0/24
+// to SE, 216.160.83.0/24 to US and 2001:218::/32 to JP.
+const fixture = "testdata/GeoLite2-Country-Test.mmdb"
+
+func TestCountry(t *testing.T) {
+	reader, err := Open(fixture)
+	require.NoError(t, err)
+	t.Cleanup(func() { reader.Close() })
+
+	tests := []struct {
+		name            string
+		ip              string
+		expectedCountry string
+		expectedErr     error
+	}{
+		{name: "ipv4", ip: "81.2.69.142", expectedCountry: "GB"},
+		{name: "another network", ip: "216.160.83.56", expectedCountry: "US"},
+		{name: "ipv6", ip: "2001:218::1", expectedCountry: "JP"},
+		{name: "ipv4 mapped ipv6", ip: "::ffff:89.160.20.112", expectedCountry: "SE"},
+		{name: "unknown address", ip: "10.0.0.1", expectedCountry: ""},
+		{name: "invalid address", ip: "not an ip", expectedErr: ErrInvalidIP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			country, err := reader.Country(tt.ip)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedCountry, country)
+		})
+	}
+}
+
+func TestOpenMissingFile(t *testing.T) {
+	_, err := Open("testdata/missing.mmdb")
+	assert.Error(t, err)
+}
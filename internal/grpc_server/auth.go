@@ -0,0 +1,62 @@
+package grpc_server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"strings"
+	"url_shortener/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type userKey struct{}
+
+// authInterceptor accepts the calls carrying the same BasicAuth credentials
+// as the HTTP API in the "authorization" metadata.
+func authInterceptor(user string, password string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !authenticated(ctx, user, password) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		return handler(context.WithValue(ctx, userKey{}, user), req)
+	}
+}
+
+func authenticated(ctx context.Context, user string, password string) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	for _, value := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(value)), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// userFromContext returns the user the call was authenticated as.
+func userFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// actorFromContext returns the user and client address of the call for the
+// audit log.
+func actorFromContext(ctx context.Context) storage.Actor {
+	actor := storage.Actor{User: userFromContext(ctx)}
+	if p, ok := peer.FromContext(ctx); ok {
+		if addr, ok := p.Addr.(*net.TCPAddr); ok {
+			actor.IP = addr.IP.String()
+		}
+	}
+	return actor
+}
@@ -0,0 +1,2044 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: shortener/v1/shortener.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Link struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Alias string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	// domain is the custom hostname of the link, empty for the default domain.
+	Domain       string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	ShortUrl     string                 `protobuf:"bytes,3,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Url          string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	Owner        string                 `protobuf:"bytes,5,opt,name=owner,proto3" json:"owner,omitempty"`
+	CreatedAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	RedirectType int32                  `protobuf:"varint,9,opt,name=redirect_type,json=redirectType,proto3" json:"redirect_type,omitempty"`
+	MaxVisits    *int32                 `protobuf:"varint,10,opt,name=max_visits,json=maxVisits,proto3,oneof" json:"max_visits,omitempty"`
+	Visits       int32                  `protobuf:"varint,11,opt,name=visits,proto3" json:"visits,omitempty"`
+	Remaining    *int32                 `protobuf:"varint,12,opt,name=remaining,proto3,oneof" json:"remaining,omitempty"`
+	LastVisitAt  *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=last_visit_at,json=lastVisitAt,proto3" json:"last_visit_at,omitempty"`
+	Interstitial bool                   `protobuf:"varint,14,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
+	Tags         []string               `protobuf:"bytes,15,rep,name=tags,proto3" json:"tags,omitempty"`
+	Folder       string                 `protobuf:"bytes,16,opt,name=folder,proto3" json:"folder,omitempty"`
+	// geo_targets maps ISO 3166-1 alpha-2 country codes to the destinations
+	// that replace url for visitors from those countries.
+	GeoTargets map[string]string `protobuf:"bytes,17,rep,name=geo_targets,json=geoTargets,proto3" json:"geo_targets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// device_rules are tried in order, the first matching rule wins over
+	// geo_targets.
+	DeviceRules []*DeviceRule `protobuf:"bytes,18,rep,name=device_rules,json=deviceRules,proto3" json:"device_rules,omitempty"`
+	// variants split the visits no targeting applies to between weighted
+	// destinations that replace url.
+	Variants []*Variant `protobuf:"bytes,19,rep,name=variants,proto3" json:"variants,omitempty"`
+	// sticky_variants serves returning visitors the variant they got first.
+	StickyVariants bool `protobuf:"varint,20,opt,name=sticky_variants,json=stickyVariants,proto3" json:"sticky_variants,omitempty"`
+	// rules are tried in order before any other targeting.
+	Rules []*RedirectRule `protobuf:"bytes,21,rep,name=rules,proto3" json:"rules,omitempty"`
+	// query_passthrough forwards the query of the short link to the
+	// destination: empty to drop it, merge or override.
+	QueryPassthrough string `protobuf:"bytes,22,opt,name=query_passthrough,json=queryPassthrough,proto3" json:"query_passthrough,omitempty"`
+	// utm are utm_* parameters added to the destination unless it already has
+	// them.
+	Utm map[string]string `protobuf:"bytes,23,rep,name=utm,proto3" json:"utm,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// prefix forwards the rest of the path after the alias to the destination.
+	Prefix bool `protobuf:"varint,24,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// blocked_at is set while the link is blocked.
+	BlockedAt     *timestamppb.Timestamp `protobuf:"bytes,25,opt,name=blocked_at,json=blockedAt,proto3" json:"blocked_at,omitempty"`
+	BlockReason   string                 `protobuf:"bytes,26,opt,name=block_reason,json=blockReason,proto3" json:"block_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Link) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *Link) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Link) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *Link) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Link) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Link) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Link) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Link) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Link) GetRedirectType() int32 {
+	if x != nil {
+		return x.RedirectType
+	}
+	return 0
+}
+
+func (x *Link) GetMaxVisits() int32 {
+	if x != nil && x.MaxVisits != nil {
+		return *x.MaxVisits
+	}
+	return 0
+}
+
+func (x *Link) GetVisits() int32 {
+	if x != nil {
+		return x.Visits
+	}
+	return 0
+}
+
+func (x *Link) GetRemaining() int32 {
+	if x != nil && x.Remaining != nil {
+		return *x.Remaining
+	}
+	return 0
+}
+
+func (x *Link) GetLastVisitAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastVisitAt
+	}
+	return nil
+}
+
+func (x *Link) GetInterstitial() bool {
+	if x != nil {
+		return x.Interstitial
+	}
+	return false
+}
+
+func (x *Link) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Link) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *Link) GetGeoTargets() map[string]string {
+	if x != nil {
+		return x.GeoTargets
+	}
+	return nil
+}
+
+func (x *Link) GetDeviceRules() []*DeviceRule {
+	if x != nil {
+		return x.DeviceRules
+	}
+	return nil
+}
+
+func (x *Link) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *Link) GetStickyVariants() bool {
+	if x != nil {
+		return x.StickyVariants
+	}
+	return false
+}
+
+func (x *Link) GetRules() []*RedirectRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *Link) GetQueryPassthrough() string {
+	if x != nil {
+		return x.QueryPassthrough
+	}
+	return ""
+}
+
+func (x *Link) GetUtm() map[string]string {
+	if x != nil {
+		return x.Utm
+	}
+	return nil
+}
+
+func (x *Link) GetPrefix() bool {
+	if x != nil {
+		return x.Prefix
+	}
+	return false
+}
+
+func (x *Link) GetBlockedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BlockedAt
+	}
+	return nil
+}
+
+func (x *Link) GetBlockReason() string {
+	if x != nil {
+		return x.BlockReason
+	}
+	return ""
+}
+
+// RedirectRule sends the visits matching all of its set conditions to url.
+type RedirectRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// days are mon, tue, wed, thu, fri, sat or sun.
+	Days []string `protobuf:"bytes,1,rep,name=days,proto3" json:"days,omitempty"`
+	// from and to bound the time of day as "15:04", to excluded.
+	From string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// timezone is the IANA zone of days, from and to, UTC when empty.
+	Timezone string                 `protobuf:"bytes,4,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	After    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=after,proto3" json:"after,omitempty"`
+	Before   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=before,proto3" json:"before,omitempty"`
+	// languages match the preferred language of Accept-Language.
+	Languages []string `protobuf:"bytes,7,rep,name=languages,proto3" json:"languages,omitempty"`
+	// query matches query parameters, an empty value matches any value.
+	Query         map[string]string `protobuf:"bytes,8,rep,name=query,proto3" json:"query,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Url           string            `protobuf:"bytes,9,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedirectRule) Reset() {
+	*x = RedirectRule{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedirectRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedirectRule) ProtoMessage() {}
+
+func (x *RedirectRule) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedirectRule.ProtoReflect.Descriptor instead.
+func (*RedirectRule) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RedirectRule) GetDays() []string {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *RedirectRule) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *RedirectRule) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *RedirectRule) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *RedirectRule) GetAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+func (x *RedirectRule) GetBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *RedirectRule) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *RedirectRule) GetQuery() map[string]string {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *RedirectRule) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// DeviceRule sends the visitors whose User-Agent matches all of its set
+// conditions to url.
+type DeviceRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// os is one of ios, android, windows, macos, linux, chromeos.
+	Os string `protobuf:"bytes,1,opt,name=os,proto3" json:"os,omitempty"`
+	// device is one of mobile, tablet, desktop, bot.
+	Device        string `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	Url           string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceRule) Reset() {
+	*x = DeviceRule{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceRule) ProtoMessage() {}
+
+func (x *DeviceRule) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceRule.ProtoReflect.Descriptor instead.
+func (*DeviceRule) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeviceRule) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *DeviceRule) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *DeviceRule) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// Variant is served to a share of the visits proportional to its weight.
+type Variant struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Weight        int32                  `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Variant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Variant) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Variant) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type CreateRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Url          string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Alias        string                 `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	MaxVisits    *int32                 `protobuf:"varint,3,opt,name=max_visits,json=maxVisits,proto3,oneof" json:"max_visits,omitempty"`
+	Interstitial bool                   `protobuf:"varint,4,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
+	ExpiresAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// redirect_type is one of 301, 302, 307, 308, 0 means 302.
+	RedirectType     int32             `protobuf:"varint,6,opt,name=redirect_type,json=redirectType,proto3" json:"redirect_type,omitempty"`
+	Tags             []string          `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	Folder           string            `protobuf:"bytes,8,opt,name=folder,proto3" json:"folder,omitempty"`
+	Domain           string            `protobuf:"bytes,9,opt,name=domain,proto3" json:"domain,omitempty"`
+	GeoTargets       map[string]string `protobuf:"bytes,10,rep,name=geo_targets,json=geoTargets,proto3" json:"geo_targets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DeviceRules      []*DeviceRule     `protobuf:"bytes,11,rep,name=device_rules,json=deviceRules,proto3" json:"device_rules,omitempty"`
+	Variants         []*Variant        `protobuf:"bytes,12,rep,name=variants,proto3" json:"variants,omitempty"`
+	StickyVariants   bool              `protobuf:"varint,13,opt,name=sticky_variants,json=stickyVariants,proto3" json:"sticky_variants,omitempty"`
+	Rules            []*RedirectRule   `protobuf:"bytes,14,rep,name=rules,proto3" json:"rules,omitempty"`
+	QueryPassthrough string            `protobuf:"bytes,15,opt,name=query_passthrough,json=queryPassthrough,proto3" json:"query_passthrough,omitempty"`
+	Utm              map[string]string `protobuf:"bytes,16,rep,name=utm,proto3" json:"utm,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Prefix           bool              `protobuf:"varint,17,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// reuse_existing returns the link the caller already has for the
+	// destination instead of creating one when alias is empty. It only applies
+	// to links without other settings.
+	ReuseExisting bool `protobuf:"varint,18,opt,name=reuse_existing,json=reuseExisting,proto3" json:"reuse_existing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetMaxVisits() int32 {
+	if x != nil && x.MaxVisits != nil {
+		return *x.MaxVisits
+	}
+	return 0
+}
+
+func (x *CreateRequest) GetInterstitial() bool {
+	if x != nil {
+		return x.Interstitial
+	}
+	return false
+}
+
+func (x *CreateRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetRedirectType() int32 {
+	if x != nil {
+		return x.RedirectType
+	}
+	return 0
+}
+
+func (x *CreateRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetGeoTargets() map[string]string {
+	if x != nil {
+		return x.GeoTargets
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetDeviceRules() []*DeviceRule {
+	if x != nil {
+		return x.DeviceRules
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetStickyVariants() bool {
+	if x != nil {
+		return x.StickyVariants
+	}
+	return false
+}
+
+func (x *CreateRequest) GetRules() []*RedirectRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetQueryPassthrough() string {
+	if x != nil {
+		return x.QueryPassthrough
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetUtm() map[string]string {
+	if x != nil {
+		return x.Utm
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetPrefix() bool {
+	if x != nil {
+		return x.Prefix
+	}
+	return false
+}
+
+func (x *CreateRequest) GetReuseExisting() bool {
+	if x != nil {
+		return x.ReuseExisting
+	}
+	return false
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *GetRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+// UpdateRequest replaces the destination and settings of an existing link.
+type UpdateRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Alias            string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain           string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	Url              string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	MaxVisits        *int32                 `protobuf:"varint,4,opt,name=max_visits,json=maxVisits,proto3,oneof" json:"max_visits,omitempty"`
+	Interstitial     bool                   `protobuf:"varint,5,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
+	ExpiresAt        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	RedirectType     int32                  `protobuf:"varint,7,opt,name=redirect_type,json=redirectType,proto3" json:"redirect_type,omitempty"`
+	Tags             []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Folder           string                 `protobuf:"bytes,9,opt,name=folder,proto3" json:"folder,omitempty"`
+	GeoTargets       map[string]string      `protobuf:"bytes,10,rep,name=geo_targets,json=geoTargets,proto3" json:"geo_targets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DeviceRules      []*DeviceRule          `protobuf:"bytes,11,rep,name=device_rules,json=deviceRules,proto3" json:"device_rules,omitempty"`
+	Variants         []*Variant             `protobuf:"bytes,12,rep,name=variants,proto3" json:"variants,omitempty"`
+	StickyVariants   bool                   `protobuf:"varint,13,opt,name=sticky_variants,json=stickyVariants,proto3" json:"sticky_variants,omitempty"`
+	Rules            []*RedirectRule        `protobuf:"bytes,14,rep,name=rules,proto3" json:"rules,omitempty"`
+	QueryPassthrough string                 `protobuf:"bytes,15,opt,name=query_passthrough,json=queryPassthrough,proto3" json:"query_passthrough,omitempty"`
+	Utm              map[string]string      `protobuf:"bytes,16,rep,name=utm,proto3" json:"utm,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Prefix           bool                   `protobuf:"varint,17,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetMaxVisits() int32 {
+	if x != nil && x.MaxVisits != nil {
+		return *x.MaxVisits
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetInterstitial() bool {
+	if x != nil {
+		return x.Interstitial
+	}
+	return false
+}
+
+func (x *UpdateRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetRedirectType() int32 {
+	if x != nil {
+		return x.RedirectType
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetGeoTargets() map[string]string {
+	if x != nil {
+		return x.GeoTargets
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetDeviceRules() []*DeviceRule {
+	if x != nil {
+		return x.DeviceRules
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetStickyVariants() bool {
+	if x != nil {
+		return x.StickyVariants
+	}
+	return false
+}
+
+func (x *UpdateRequest) GetRules() []*RedirectRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetQueryPassthrough() string {
+	if x != nil {
+		return x.QueryPassthrough
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetUtm() map[string]string {
+	if x != nil {
+		return x.Utm
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetPrefix() bool {
+	if x != nil {
+		return x.Prefix
+	}
+	return false
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{8}
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// domain lists the links of one domain, those of all domains when empty.
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Tag    string `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	// folder also matches the links of its subfolders.
+	Folder string `protobuf:"bytes,3,opt,name=folder,proto3" json:"folder,omitempty"`
+	// limit defaults to 50 and can be at most 1000.
+	Limit         int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ListRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListRequest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Links         []*Link                `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListResponse) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{11}
+}
+
+type StatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []*TagStats            `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StatsResponse) GetTags() []*TagStats {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type TagStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Links         int32                  `protobuf:"varint,2,opt,name=links,proto3" json:"links,omitempty"`
+	Visits        int32                  `protobuf:"varint,3,opt,name=visits,proto3" json:"visits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagStats) Reset() {
+	*x = TagStats{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagStats) ProtoMessage() {}
+
+func (x *TagStats) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagStats.ProtoReflect.Descriptor instead.
+func (*TagStats) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TagStats) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TagStats) GetLinks() int32 {
+	if x != nil {
+		return x.Links
+	}
+	return 0
+}
+
+func (x *TagStats) GetVisits() int32 {
+	if x != nil {
+		return x.Visits
+	}
+	return 0
+}
+
+type LinkStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkStatsRequest) Reset() {
+	*x = LinkStatsRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkStatsRequest) ProtoMessage() {}
+
+func (x *LinkStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkStatsRequest.ProtoReflect.Descriptor instead.
+func (*LinkStatsRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LinkStatsRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *LinkStatsRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type LinkStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	Visits        int32                  `protobuf:"varint,3,opt,name=visits,proto3" json:"visits,omitempty"`
+	Variants      []*VariantStats        `protobuf:"bytes,4,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkStatsResponse) Reset() {
+	*x = LinkStatsResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkStatsResponse) ProtoMessage() {}
+
+func (x *LinkStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkStatsResponse.ProtoReflect.Descriptor instead.
+func (*LinkStatsResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LinkStatsResponse) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *LinkStatsResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *LinkStatsResponse) GetVisits() int32 {
+	if x != nil {
+		return x.Visits
+	}
+	return 0
+}
+
+func (x *LinkStatsResponse) GetVariants() []*VariantStats {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+type VariantStats struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Name   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Url    string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Weight int32                  `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	// clicks counts the visits the variant was served to.
+	Clicks        int32 `protobuf:"varint,4,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VariantStats) Reset() {
+	*x = VariantStats{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VariantStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VariantStats) ProtoMessage() {}
+
+func (x *VariantStats) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VariantStats.ProtoReflect.Descriptor instead.
+func (*VariantStats) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *VariantStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *VariantStats) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *VariantStats) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *VariantStats) GetClicks() int32 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+type DryRunRulesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Alias  string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// time is when the visit happens, now when unset.
+	Time           *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	AcceptLanguage string                 `protobuf:"bytes,4,opt,name=accept_language,json=acceptLanguage,proto3" json:"accept_language,omitempty"`
+	// query is the query string of the short link, such as "utm_source=mail".
+	Query         string `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DryRunRulesRequest) Reset() {
+	*x = DryRunRulesRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DryRunRulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunRulesRequest) ProtoMessage() {}
+
+func (x *DryRunRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunRulesRequest.ProtoReflect.Descriptor instead.
+func (*DryRunRulesRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DryRunRulesRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *DryRunRulesRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *DryRunRulesRequest) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *DryRunRulesRequest) GetAcceptLanguage() string {
+	if x != nil {
+		return x.AcceptLanguage
+	}
+	return ""
+}
+
+func (x *DryRunRulesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type DryRunRulesResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Matched bool                   `protobuf:"varint,1,opt,name=matched,proto3" json:"matched,omitempty"`
+	// rule is the index of the matching rule.
+	Rule          int32  `protobuf:"varint,2,opt,name=rule,proto3" json:"rule,omitempty"`
+	Url           string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DryRunRulesResponse) Reset() {
+	*x = DryRunRulesResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DryRunRulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunRulesResponse) ProtoMessage() {}
+
+func (x *DryRunRulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunRulesResponse.ProtoReflect.Descriptor instead.
+func (*DryRunRulesResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DryRunRulesResponse) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+func (x *DryRunRulesResponse) GetRule() int32 {
+	if x != nil {
+		return x.Rule
+	}
+	return 0
+}
+
+func (x *DryRunRulesResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type BlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockRequest) Reset() {
+	*x = BlockRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRequest) ProtoMessage() {}
+
+func (x *BlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRequest.ProtoReflect.Descriptor instead.
+func (*BlockRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BlockRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *BlockRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *BlockRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UnblockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnblockRequest) Reset() {
+	*x = UnblockRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnblockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnblockRequest) ProtoMessage() {}
+
+func (x *UnblockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnblockRequest.ProtoReflect.Descriptor instead.
+func (*UnblockRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UnblockRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *UnblockRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+var File_shortener_v1_shortener_proto protoreflect.FileDescriptor
+
+const file_shortener_v1_shortener_proto_rawDesc = "" +
+	"\n" +
+	"\x1cshortener/v1/shortener.proto\x12\fshortener.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb4\t\n" +
+	"\x04Link\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x1b\n" +
+	"\tshort_url\x18\x03 \x01(\tR\bshortUrl\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x14\n" +
+	"\x05owner\x18\x05 \x01(\tR\x05owner\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12#\n" +
+	"\rredirect_type\x18\t \x01(\x05R\fredirectType\x12\"\n" +
+	"\n" +
+	"max_visits\x18\n" +
+	" \x01(\x05H\x00R\tmaxVisits\x88\x01\x01\x12\x16\n" +
+	"\x06visits\x18\v \x01(\x05R\x06visits\x12!\n" +
+	"\tremaining\x18\f \x01(\x05H\x01R\tremaining\x88\x01\x01\x12>\n" +
+	"\rlast_visit_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\vlastVisitAt\x12\"\n" +
+	"\finterstitial\x18\x0e \x01(\bR\finterstitial\x12\x12\n" +
+	"\x04tags\x18\x0f \x03(\tR\x04tags\x12\x16\n" +
+	"\x06folder\x18\x10 \x01(\tR\x06folder\x12C\n" +
+	"\vgeo_targets\x18\x11 \x03(\v2\".shortener.v1.Link.GeoTargetsEntryR\n" +
+	"geoTargets\x12;\n" +
+	"\fdevice_rules\x18\x12 \x03(\v2\x18.shortener.v1.DeviceRuleR\vdeviceRules\x121\n" +
+	"\bvariants\x18\x13 \x03(\v2\x15.shortener.v1.VariantR\bvariants\x12'\n" +
+	"\x0fsticky_variants\x18\x14 \x01(\bR\x0estickyVariants\x120\n" +
+	"\x05rules\x18\x15 \x03(\v2\x1a.shortener.v1.RedirectRuleR\x05rules\x12+\n" +
+	"\x11query_passthrough\x18\x16 \x01(\tR\x10queryPassthrough\x12-\n" +
+	"\x03utm\x18\x17 \x03(\v2\x1b.shortener.v1.Link.UtmEntryR\x03utm\x12\x16\n" +
+	"\x06prefix\x18\x18 \x01(\bR\x06prefix\x129\n" +
+	"\n" +
+	"blocked_at\x18\x19 \x01(\v2\x1a.google.protobuf.TimestampR\tblockedAt\x12!\n" +
+	"\fblock_reason\x18\x1a \x01(\tR\vblockReason\x1a=\n" +
+	"\x0fGeoTargetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a6\n" +
+	"\bUtmEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\r\n" +
+	"\v_max_visitsB\f\n" +
+	"\n" +
+	"_remaining\"\xef\x02\n" +
+	"\fRedirectRule\x12\x12\n" +
+	"\x04days\x18\x01 \x03(\tR\x04days\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x1a\n" +
+	"\btimezone\x18\x04 \x01(\tR\btimezone\x120\n" +
+	"\x05after\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05after\x122\n" +
+	"\x06before\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x06before\x12\x1c\n" +
+	"\tlanguages\x18\a \x03(\tR\tlanguages\x12;\n" +
+	"\x05query\x18\b \x03(\v2%.shortener.v1.RedirectRule.QueryEntryR\x05query\x12\x10\n" +
+	"\x03url\x18\t \x01(\tR\x03url\x1a8\n" +
+	"\n" +
+	"QueryEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"F\n" +
+	"\n" +
+	"DeviceRule\x12\x0e\n" +
+	"\x02os\x18\x01 \x01(\tR\x02os\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\"G\n" +
+	"\aVariant\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x05R\x06weight\"\xe6\x06\n" +
+	"\rCreateRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\"\n" +
+	"\n" +
+	"max_visits\x18\x03 \x01(\x05H\x00R\tmaxVisits\x88\x01\x01\x12\"\n" +
+	"\finterstitial\x18\x04 \x01(\bR\finterstitial\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12#\n" +
+	"\rredirect_type\x18\x06 \x01(\x05R\fredirectType\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12\x16\n" +
+	"\x06folder\x18\b \x01(\tR\x06folder\x12\x16\n" +
+	"\x06domain\x18\t \x01(\tR\x06domain\x12L\n" +
+	"\vgeo_targets\x18\n" +
+	" \x03(\v2+.shortener.v1.CreateRequest.GeoTargetsEntryR\n" +
+	"geoTargets\x12;\n" +
+	"\fdevice_rules\x18\v \x03(\v2\x18.shortener.v1.DeviceRuleR\vdeviceRules\x121\n" +
+	"\bvariants\x18\f \x03(\v2\x15.shortener.v1.VariantR\bvariants\x12'\n" +
+	"\x0fsticky_variants\x18\r \x01(\bR\x0estickyVariants\x120\n" +
+	"\x05rules\x18\x0e \x03(\v2\x1a.shortener.v1.RedirectRuleR\x05rules\x12+\n" +
+	"\x11query_passthrough\x18\x0f \x01(\tR\x10queryPassthrough\x126\n" +
+	"\x03utm\x18\x10 \x03(\v2$.shortener.v1.CreateRequest.UtmEntryR\x03utm\x12\x16\n" +
+	"\x06prefix\x18\x11 \x01(\bR\x06prefix\x12%\n" +
+	"\x0ereuse_existing\x18\x12 \x01(\bR\rreuseExisting\x1a=\n" +
+	"\x0fGeoTargetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a6\n" +
+	"\bUtmEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\r\n" +
+	"\v_max_visits\":\n" +
+	"\n" +
+	"GetRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"\xbf\x06\n" +
+	"\rUpdateRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\"\n" +
+	"\n" +
+	"max_visits\x18\x04 \x01(\x05H\x00R\tmaxVisits\x88\x01\x01\x12\"\n" +
+	"\finterstitial\x18\x05 \x01(\bR\finterstitial\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12#\n" +
+	"\rredirect_type\x18\a \x01(\x05R\fredirectType\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12\x16\n" +
+	"\x06folder\x18\t \x01(\tR\x06folder\x12L\n" +
+	"\vgeo_targets\x18\n" +
+	" \x03(\v2+.shortener.v1.UpdateRequest.GeoTargetsEntryR\n" +
+	"geoTargets\x12;\n" +
+	"\fdevice_rules\x18\v \x03(\v2\x18.shortener.v1.DeviceRuleR\vdeviceRules\x121\n" +
+	"\bvariants\x18\f \x03(\v2\x15.shortener.v1.VariantR\bvariants\x12'\n" +
+	"\x0fsticky_variants\x18\r \x01(\bR\x0estickyVariants\x120\n" +
+	"\x05rules\x18\x0e \x03(\v2\x1a.shortener.v1.RedirectRuleR\x05rules\x12+\n" +
+	"\x11query_passthrough\x18\x0f \x01(\tR\x10queryPassthrough\x126\n" +
+	"\x03utm\x18\x10 \x03(\v2$.shortener.v1.UpdateRequest.UtmEntryR\x03utm\x12\x16\n" +
+	"\x06prefix\x18\x11 \x01(\bR\x06prefix\x1a=\n" +
+	"\x0fGeoTargetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a6\n" +
+	"\bUtmEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\r\n" +
+	"\v_max_visits\"=\n" +
+	"\rDeleteRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"\x10\n" +
+	"\x0eDeleteResponse\"}\n" +
+	"\vListRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x16\n" +
+	"\x06folder\x18\x03 \x01(\tR\x06folder\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\"8\n" +
+	"\fListResponse\x12(\n" +
+	"\x05links\x18\x01 \x03(\v2\x12.shortener.v1.LinkR\x05links\"\x0e\n" +
+	"\fStatsRequest\";\n" +
+	"\rStatsResponse\x12*\n" +
+	"\x04tags\x18\x01 \x03(\v2\x16.shortener.v1.TagStatsR\x04tags\"J\n" +
+	"\bTagStats\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05links\x18\x02 \x01(\x05R\x05links\x12\x16\n" +
+	"\x06visits\x18\x03 \x01(\x05R\x06visits\"@\n" +
+	"\x10LinkStatsRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"\x91\x01\n" +
+	"\x11LinkStatsResponse\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06visits\x18\x03 \x01(\x05R\x06visits\x126\n" +
+	"\bvariants\x18\x04 \x03(\v2\x1a.shortener.v1.VariantStatsR\bvariants\"d\n" +
+	"\fVariantStats\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x05R\x06weight\x12\x16\n" +
+	"\x06clicks\x18\x04 \x01(\x05R\x06clicks\"\xb1\x01\n" +
+	"\x12DryRunRulesRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12'\n" +
+	"\x0faccept_language\x18\x04 \x01(\tR\x0eacceptLanguage\x12\x14\n" +
+	"\x05query\x18\x05 \x01(\tR\x05query\"U\n" +
+	"\x13DryRunRulesResponse\x12\x18\n" +
+	"\amatched\x18\x01 \x01(\bR\amatched\x12\x12\n" +
+	"\x04rule\x18\x02 \x01(\x05R\x04rule\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\"T\n" +
+	"\fBlockRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\">\n" +
+	"\x0eUnblockRequest\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain2\x94\x05\n" +
+	"\tShortener\x129\n" +
+	"\x06Create\x12\x1b.shortener.v1.CreateRequest\x1a\x12.shortener.v1.Link\x123\n" +
+	"\x03Get\x12\x18.shortener.v1.GetRequest\x1a\x12.shortener.v1.Link\x129\n" +
+	"\x06Update\x12\x1b.shortener.v1.UpdateRequest\x1a\x12.shortener.v1.Link\x12C\n" +
+	"\x06Delete\x12\x1b.shortener.v1.DeleteRequest\x1a\x1c.shortener.v1.DeleteResponse\x12=\n" +
+	"\x04List\x12\x19.shortener.v1.ListRequest\x1a\x1a.shortener.v1.ListResponse\x12@\n" +
+	"\x05Stats\x12\x1a.shortener.v1.StatsRequest\x1a\x1b.shortener.v1.StatsResponse\x12L\n" +
+	"\tLinkStats\x12\x1e.shortener.v1.LinkStatsRequest\x1a\x1f.shortener.v1.LinkStatsResponse\x12R\n" +
+	"\vDryRunRules\x12 .shortener.v1.DryRunRulesRequest\x1a!.shortener.v1.DryRunRulesResponse\x127\n" +
+	"\x05Block\x12\x1a.shortener.v1.BlockRequest\x1a\x12.shortener.v1.Link\x12;\n" +
+	"\aUnblock\x12\x1c.shortener.v1.UnblockRequest\x1a\x12.shortener.v1.LinkB'Z%url_shortener/internal/grpc_server/pbb\x06proto3"
+
+var (
+	file_shortener_v1_shortener_proto_rawDescOnce sync.Once
+	file_shortener_v1_shortener_proto_rawDescData []byte
+)
+
+func file_shortener_v1_shortener_proto_rawDescGZIP() []byte {
+	file_shortener_v1_shortener_proto_rawDescOnce.Do(func() {
+		file_shortener_v1_shortener_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_shortener_v1_shortener_proto_rawDesc), len(file_shortener_v1_shortener_proto_rawDesc)))
+	})
+	return file_shortener_v1_shortener_proto_rawDescData
+}
+
+var file_shortener_v1_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_shortener_v1_shortener_proto_goTypes = []any{
+	(*Link)(nil),                  // 0: shortener.v1.Link
+	(*RedirectRule)(nil),          // 1: shortener.v1.RedirectRule
+	(*DeviceRule)(nil),            // 2: shortener.v1.DeviceRule
+	(*Variant)(nil),               // 3: shortener.v1.Variant
+	(*CreateRequest)(nil),         // 4: shortener.v1.CreateRequest
+	(*GetRequest)(nil),            // 5: shortener.v1.GetRequest
+	(*UpdateRequest)(nil),         // 6: shortener.v1.UpdateRequest
+	(*DeleteRequest)(nil),         // 7: shortener.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 8: shortener.v1.DeleteResponse
+	(*ListRequest)(nil),           // 9: shortener.v1.ListRequest
+	(*ListResponse)(nil),          // 10: shortener.v1.ListResponse
+	(*StatsRequest)(nil),          // 11: shortener.v1.StatsRequest
+	(*StatsResponse)(nil),         // 12: shortener.v1.StatsResponse
+	(*TagStats)(nil),              // 13: shortener.v1.TagStats
+	(*LinkStatsRequest)(nil),      // 14: shortener.v1.LinkStatsRequest
+	(*LinkStatsResponse)(nil),     // 15: shortener.v1.LinkStatsResponse
+	(*VariantStats)(nil),          // 16: shortener.v1.VariantStats
+	(*DryRunRulesRequest)(nil),    // 17: shortener.v1.DryRunRulesRequest
+	(*DryRunRulesResponse)(nil),   // 18: shortener.v1.DryRunRulesResponse
+	(*BlockRequest)(nil),          // 19: shortener.v1.BlockRequest
+	(*UnblockRequest)(nil),        // 20: shortener.v1.UnblockRequest
+	nil,                           // 21: shortener.v1.Link.GeoTargetsEntry
+	nil,                           // 22: shortener.v1.Link.UtmEntry
+	nil,                           // 23: shortener.v1.RedirectRule.QueryEntry
+	nil,                           // 24: shortener.v1.CreateRequest.GeoTargetsEntry
+	nil,                           // 25: shortener.v1.CreateRequest.UtmEntry
+	nil,                           // 26: shortener.v1.UpdateRequest.GeoTargetsEntry
+	nil,                           // 27: shortener.v1.UpdateRequest.UtmEntry
+	(*timestamppb.Timestamp)(nil), // 28: google.protobuf.Timestamp
+}
+var file_shortener_v1_shortener_proto_depIdxs = []int32{
+	28, // 0: shortener.v1.Link.created_at:type_name -> google.protobuf.Timestamp
+	28, // 1: shortener.v1.Link.updated_at:type_name -> google.protobuf.Timestamp
+	28, // 2: shortener.v1.Link.expires_at:type_name -> google.protobuf.Timestamp
+	28, // 3: shortener.v1.Link.last_visit_at:type_name -> google.protobuf.Timestamp
+	21, // 4: shortener.v1.Link.geo_targets:type_name -> shortener.v1.Link.GeoTargetsEntry
+	2,  // 5: shortener.v1.Link.device_rules:type_name -> shortener.v1.DeviceRule
+	3,  // 6: shortener.v1.Link.variants:type_name -> shortener.v1.Variant
+	1,  // 7: shortener.v1.Link.rules:type_name -> shortener.v1.RedirectRule
+	22, // 8: shortener.v1.Link.utm:type_name -> shortener.v1.Link.UtmEntry
+	28, // 9: shortener.v1.Link.blocked_at:type_name -> google.protobuf.Timestamp
+	28, // 10: shortener.v1.RedirectRule.after:type_name -> google.protobuf.Timestamp
+	28, // 11: shortener.v1.RedirectRule.before:type_name -> google.protobuf.Timestamp
+	23, // 12: shortener.v1.RedirectRule.query:type_name -> shortener.v1.RedirectRule.QueryEntry
+	28, // 13: shortener.v1.CreateRequest.expires_at:type_name -> google.protobuf.Timestamp
+	24, // 14: shortener.v1.CreateRequest.geo_targets:type_name -> shortener.v1.CreateRequest.GeoTargetsEntry
+	2,  // 15: shortener.v1.CreateRequest.device_rules:type_name -> shortener.v1.DeviceRule
+	3,  // 16: shortener.v1.CreateRequest.variants:type_name -> shortener.v1.Variant
+	1,  // 17: shortener.v1.CreateRequest.rules:type_name -> shortener.v1.RedirectRule
+	25, // 18: shortener.v1.CreateRequest.utm:type_name -> shortener.v1.CreateRequest.UtmEntry
+	28, // 19: shortener.v1.UpdateRequest.expires_at:type_name -> google.protobuf.Timestamp
+	26, // 20: shortener.v1.UpdateRequest.geo_targets:type_name -> shortener.v1.UpdateRequest.GeoTargetsEntry
+	2,  // 21: shortener.v1.UpdateRequest.device_rules:type_name -> shortener.v1.DeviceRule
+	3,  // 22: shortener.v1.UpdateRequest.variants:type_name -> shortener.v1.Variant
+	1,  // 23: shortener.v1.UpdateRequest.rules:type_name -> shortener.v1.RedirectRule
+	27, // 24: shortener.v1.UpdateRequest.utm:type_name -> shortener.v1.UpdateRequest.UtmEntry
+	0,  // 25: shortener.v1.ListResponse.links:type_name -> shortener.v1.Link
+	13, // 26: shortener.v1.StatsResponse.tags:type_name -> shortener.v1.TagStats
+	16, // 27: shortener.v1.LinkStatsResponse.variants:type_name -> shortener.v1.VariantStats
+	28, // 28: shortener.v1.DryRunRulesRequest.time:type_name -> google.protobuf.Timestamp
+	4,  // 29: shortener.v1.Shortener.Create:input_type -> shortener.v1.CreateRequest
+	5,  // 30: shortener.v1.Shortener.Get:input_type -> shortener.v1.GetRequest
+	6,  // 31: shortener.v1.Shortener.Update:input_type -> shortener.v1.UpdateRequest
+	7,  // 32: shortener.v1.Shortener.Delete:input_type -> shortener.v1.DeleteRequest
+	9,  // 33: shortener.v1.Shortener.List:input_type -> shortener.v1.ListRequest
+	11, // 34: shortener.v1.Shortener.Stats:input_type -> shortener.v1.StatsRequest
+	14, // 35: shortener.v1.Shortener.LinkStats:input_type -> shortener.v1.LinkStatsRequest
+	17, // 36: shortener.v1.Shortener.DryRunRules:input_type -> shortener.v1.DryRunRulesRequest
+	19, // 37: shortener.v1.Shortener.Block:input_type -> shortener.v1.BlockRequest
+	20, // 38: shortener.v1.Shortener.Unblock:input_type -> shortener.v1.UnblockRequest
+	0,  // 39: shortener.v1.Shortener.Create:output_type -> shortener.v1.Link
+	0,  // 40: shortener.v1.Shortener.Get:output_type -> shortener.v1.Link
+	0,  // 41: shortener.v1.Shortener.Update:output_type -> shortener.v1.Link
+	8,  // 42: shortener.v1.Shortener.Delete:output_type -> shortener.v1.DeleteResponse
+	10, // 43: shortener.v1.Shortener.List:output_type -> shortener.v1.ListResponse
+	12, // 44: shortener.v1.Shortener.Stats:output_type -> shortener.v1.StatsResponse
+	15, // 45: shortener.v1.Shortener.LinkStats:output_type -> shortener.v1.LinkStatsResponse
+	18, // 46: shortener.v1.Shortener.DryRunRules:output_type -> shortener.v1.DryRunRulesResponse
+	0,  // 47: shortener.v1.Shortener.Block:output_type -> shortener.v1.Link
+	0,  // 48: shortener.v1.Shortener.Unblock:output_type -> shortener.v1.Link
+	39, // [39:49] is the sub-list for method output_type
+	29, // [29:39] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
+}
+
+func init() { file_shortener_v1_shortener_proto_init() }
+func file_shortener_v1_shortener_proto_init() {
+	if File_shortener_v1_shortener_proto != nil {
+		return
+	}
+	file_shortener_v1_shortener_proto_msgTypes[0].OneofWrappers = []any{}
+	file_shortener_v1_shortener_proto_msgTypes[4].OneofWrappers = []any{}
+	file_shortener_v1_shortener_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_shortener_v1_shortener_proto_rawDesc), len(file_shortener_v1_shortener_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_shortener_v1_shortener_proto_goTypes,
+		DependencyIndexes: file_shortener_v1_shortener_proto_depIdxs,
+		MessageInfos:      file_shortener_v1_shortener_proto_msgTypes,
+	}.Build()
+	File_shortener_v1_shortener_proto = out.File
+	file_shortener_v1_shortener_proto_goTypes = nil
+	file_shortener_v1_shortener_proto_depIdxs = nil
+}
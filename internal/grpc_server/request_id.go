@@ -0,0 +1,30 @@
+package grpc_server
+
+import (
+	"context"
+	"strings"
+	"url_shortener/internal/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDInterceptor keeps the x-request-id metadata sent by the client, or
+// generates one, puts it in the call context and returns it in the header.
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	key := strings.ToLower(logger.RequestIDHeader)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		if !logger.ValidRequestID(id) {
+			id = logger.NewRequestID()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(key, id))
+		return handler(logger.WithRequestID(ctx, id), req)
+	}
+}
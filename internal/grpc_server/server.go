@@ -0,0 +1,466 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/grpc_server/pb"
+	"url_shortener/internal/services"
+	"url_shortener/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type shortenerServer struct {
+	pb.UnimplementedShortenerServer
+	urlService   services.UrlService
+	shortURLBase url.URL
+	log          *slog.Logger
+}
+
+// New creates a gRPC server exposing the url service. shortURLBase is the
+// public URL aliases are appended to, such as "https://sho.rt/url".
+func New(urlService services.UrlService, cfg config.Config, shortURLBase string, logger *slog.Logger) *grpc.Server {
+	base, err := url.Parse(strings.TrimSuffix(shortURLBase, "/"))
+	if err != nil {
+		panic(fmt.Sprintf("invalid short url base %q: %s", shortURLBase, err))
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestIDInterceptor(),
+		authInterceptor(cfg.HttpServer.User, cfg.HttpServer.Password),
+	))
+	pb.RegisterShortenerServer(server, &shortenerServer{urlService: urlService, shortURLBase: *base, log: logger})
+	return server
+}
+
+func (s *shortenerServer) Create(ctx context.Context, req *pb.CreateRequest) (*pb.Link, error) {
+	const fn = "grpc_server.server.Create"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetUrl() == "" {
+		log.ErrorContext(ctx, "missing required field", slog.String("field", "url"))
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+
+	link, err := s.urlService.SaveURL(ctx, storage.URL{
+		URL:              req.GetUrl(),
+		Alias:            req.GetAlias(),
+		MaxVisits:        optionalInt(req.MaxVisits),
+		Interstitial:     req.GetInterstitial(),
+		ExpiresAt:        optionalTime(req.GetExpiresAt()),
+		RedirectType:     int(req.GetRedirectType()),
+		Tags:             req.GetTags(),
+		Folder:           req.GetFolder(),
+		Domain:           req.GetDomain(),
+		GeoTargets:       req.GetGeoTargets(),
+		DeviceRules:      deviceRules(req.GetDeviceRules()),
+		Variants:         variants(req.GetVariants()),
+		StickyVariants:   req.GetStickyVariants(),
+		Rules:            rules(req.GetRules()),
+		QueryPassthrough: req.GetQueryPassthrough(),
+		UTM:              req.GetUtm(),
+		Prefix:           req.GetPrefix(),
+		Owner:            userFromContext(ctx),
+	}, req.GetReuseExisting(), actorFromContext(ctx))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create link", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	return s.link(link), nil
+}
+
+func (s *shortenerServer) Get(ctx context.Context, req *pb.GetRequest) (*pb.Link, error) {
+	const fn = "grpc_server.server.Get"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	link, err := s.urlService.GetURLInfo(ctx, req.GetDomain(), req.GetAlias())
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get link", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	return s.link(link), nil
+}
+
+func (s *shortenerServer) Update(ctx context.Context, req *pb.UpdateRequest) (*pb.Link, error) {
+	const fn = "grpc_server.server.Update"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+	if req.GetUrl() == "" {
+		log.ErrorContext(ctx, "missing required field", slog.String("field", "url"))
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+
+	link, err := s.urlService.UpdateURL(ctx, storage.URL{
+		URL:              req.GetUrl(),
+		Alias:            req.GetAlias(),
+		MaxVisits:        optionalInt(req.MaxVisits),
+		Interstitial:     req.GetInterstitial(),
+		ExpiresAt:        optionalTime(req.GetExpiresAt()),
+		RedirectType:     int(req.GetRedirectType()),
+		Tags:             req.GetTags(),
+		Folder:           req.GetFolder(),
+		Domain:           req.GetDomain(),
+		GeoTargets:       req.GetGeoTargets(),
+		DeviceRules:      deviceRules(req.GetDeviceRules()),
+		Variants:         variants(req.GetVariants()),
+		StickyVariants:   req.GetStickyVariants(),
+		Rules:            rules(req.GetRules()),
+		QueryPassthrough: req.GetQueryPassthrough(),
+		UTM:              req.GetUtm(),
+		Prefix:           req.GetPrefix(),
+	}, actorFromContext(ctx))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update link", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	return s.link(link), nil
+}
+
+func (s *shortenerServer) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	const fn = "grpc_server.server.Delete"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	if err := s.urlService.DeleteURL(ctx, req.GetDomain(), req.GetAlias(), actorFromContext(ctx)); err != nil {
+		log.ErrorContext(ctx, "failed to delete link", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *shortenerServer) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	const fn = "grpc_server.server.List"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	links, err := s.urlService.ListURLs(ctx, storage.URLFilter{
+		Domain:     req.GetDomain(),
+		AllDomains: req.GetDomain() == "",
+		Tag:        req.GetTag(),
+		Folder:     req.GetFolder(),
+		Limit:      int(req.GetLimit()),
+		Offset:     int(req.GetOffset()),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "failed to list links", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	response := &pb.ListResponse{Links: make([]*pb.Link, 0, len(links))}
+	for _, link := range links {
+		response.Links = append(response.Links, s.link(link))
+	}
+
+	return response, nil
+}
+
+func (s *shortenerServer) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsResponse, error) {
+	const fn = "grpc_server.server.Stats"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	stats, err := s.urlService.TagStats(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get tag stats", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	response := &pb.StatsResponse{Tags: make([]*pb.TagStats, 0, len(stats))}
+	for _, st := range stats {
+		response.Tags = append(response.Tags, &pb.TagStats{Tag: st.Tag, Links: int32(st.Links), Visits: int32(st.Visits)})
+	}
+
+	return response, nil
+}
+
+func (s *shortenerServer) LinkStats(ctx context.Context, req *pb.LinkStatsRequest) (*pb.LinkStatsResponse, error) {
+	const fn = "grpc_server.server.LinkStats"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	stats, err := s.urlService.LinkStats(ctx, req.GetDomain(), req.GetAlias())
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get link stats", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	response := &pb.LinkStatsResponse{
+		Alias:    stats.Link.Alias,
+		Domain:   stats.Link.Domain,
+		Visits:   int32(stats.Link.Visits),
+		Variants: make([]*pb.VariantStats, 0, len(stats.Link.Variants)),
+	}
+	for _, variant := range stats.Link.Variants {
+		response.Variants = append(response.Variants, &pb.VariantStats{
+			Name:   variant.Name,
+			Url:    variant.URL,
+			Weight: int32(variant.Weight),
+			Clicks: int32(stats.VariantClicks[variant.Name]),
+		})
+	}
+
+	return response, nil
+}
+
+func (s *shortenerServer) DryRunRules(ctx context.Context, req *pb.DryRunRulesRequest) (*pb.DryRunRulesResponse, error) {
+	const fn = "grpc_server.server.DryRunRules"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	query, err := url.ParseQuery(req.GetQuery())
+	if err != nil {
+		log.ErrorContext(ctx, "invalid query", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, status.Error(codes.InvalidArgument, "query must be a URL query string")
+	}
+
+	at := time.Now()
+	if req.GetTime() != nil {
+		at = req.GetTime().AsTime()
+	}
+
+	match, err := s.urlService.MatchRule(ctx, req.GetDomain(), req.GetAlias(), services.Visitor{AcceptLanguage: req.GetAcceptLanguage(), Query: query}, at)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to match the rules", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	if !match.Matched {
+		return &pb.DryRunRulesResponse{}, nil
+	}
+	return &pb.DryRunRulesResponse{Matched: true, Rule: int32(match.Index), Url: match.Rule.URL}, nil
+}
+
+func (s *shortenerServer) Block(ctx context.Context, req *pb.BlockRequest) (*pb.Link, error) {
+	const fn = "grpc_server.server.Block"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	link, err := s.urlService.BlockURL(ctx, req.GetDomain(), req.GetAlias(), req.GetReason(), actorFromContext(ctx))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to block link", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	return s.link(link), nil
+}
+
+func (s *shortenerServer) Unblock(ctx context.Context, req *pb.UnblockRequest) (*pb.Link, error) {
+	const fn = "grpc_server.server.Unblock"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if req.GetAlias() == "" {
+		log.ErrorContext(ctx, "alias is empty")
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	link, err := s.urlService.UnblockURL(ctx, req.GetDomain(), req.GetAlias(), actorFromContext(ctx))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to unblock link", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, statusError(err)
+	}
+
+	return s.link(link), nil
+}
+
+// statusError maps the service errors to gRPC status codes, the same way the
+// HTTP controllers map them to status codes.
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrURLAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, services.ErrURLNotFound):
+		return status.Error(codes.NotFound, "URL not found")
+	case errors.Is(err, services.ErrReputationUnavailable):
+		return status.Error(codes.Unavailable, services.ErrReputationUnavailable.Error())
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+func (s *shortenerServer) link(link storage.URL) *pb.Link {
+	return &pb.Link{
+		Alias:            link.Alias,
+		Domain:           link.Domain,
+		ShortUrl:         link.ShortURL(s.shortURLBase),
+		Url:              link.URL,
+		Owner:            link.Owner,
+		CreatedAt:        timestamppb.New(link.CreatedAt),
+		UpdatedAt:        timestamp(link.UpdatedAt),
+		ExpiresAt:        timestamp(link.ExpiresAt),
+		RedirectType:     int32(link.RedirectType),
+		MaxVisits:        optionalInt32(link.MaxVisits),
+		Visits:           int32(link.Visits),
+		Remaining:        optionalInt32(link.Remaining()),
+		LastVisitAt:      timestamp(link.LastVisitAt),
+		Interstitial:     link.Interstitial,
+		Tags:             link.Tags,
+		Folder:           link.Folder,
+		GeoTargets:       link.GeoTargets,
+		DeviceRules:      pbDeviceRules(link.DeviceRules),
+		Variants:         pbVariants(link.Variants),
+		StickyVariants:   link.StickyVariants,
+		Rules:            pbRules(link.Rules),
+		QueryPassthrough: link.QueryPassthrough,
+		Utm:              link.UTM,
+		Prefix:           link.Prefix,
+		BlockedAt:        timestamp(link.BlockedAt),
+		BlockReason:      link.BlockReason,
+	}
+}
+
+func deviceRules(rules []*pb.DeviceRule) []storage.DeviceRule {
+	var converted []storage.DeviceRule
+	for _, rule := range rules {
+		converted = append(converted, storage.DeviceRule{OS: rule.GetOs(), Device: rule.GetDevice(), URL: rule.GetUrl()})
+	}
+	return converted
+}
+
+func pbDeviceRules(rules []storage.DeviceRule) []*pb.DeviceRule {
+	var converted []*pb.DeviceRule
+	for _, rule := range rules {
+		converted = append(converted, &pb.DeviceRule{Os: rule.OS, Device: rule.Device, Url: rule.URL})
+	}
+	return converted
+}
+
+func rules(rules []*pb.RedirectRule) []storage.RedirectRule {
+	var converted []storage.RedirectRule
+	for _, rule := range rules {
+		converted = append(converted, storage.RedirectRule{
+			Days:      rule.GetDays(),
+			From:      rule.GetFrom(),
+			To:        rule.GetTo(),
+			Timezone:  rule.GetTimezone(),
+			After:     optionalTime(rule.GetAfter()),
+			Before:    optionalTime(rule.GetBefore()),
+			Languages: rule.GetLanguages(),
+			Query:     rule.GetQuery(),
+			URL:       rule.GetUrl(),
+		})
+	}
+	return converted
+}
+
+func pbRules(rules []storage.RedirectRule) []*pb.RedirectRule {
+	var converted []*pb.RedirectRule
+	for _, rule := range rules {
+		converted = append(converted, &pb.RedirectRule{
+			Days:      rule.Days,
+			From:      rule.From,
+			To:        rule.To,
+			Timezone:  rule.Timezone,
+			After:     timestamp(rule.After),
+			Before:    timestamp(rule.Before),
+			Languages: rule.Languages,
+			Query:     rule.Query,
+			Url:       rule.URL,
+		})
+	}
+	return converted
+}
+
+func variants(variants []*pb.Variant) []storage.Variant {
+	var converted []storage.Variant
+	for _, variant := range variants {
+		converted = append(converted, storage.Variant{Name: variant.GetName(), URL: variant.GetUrl(), Weight: int(variant.GetWeight())})
+	}
+	return converted
+}
+
+func pbVariants(variants []storage.Variant) []*pb.Variant {
+	var converted []*pb.Variant
+	for _, variant := range variants {
+		converted = append(converted, &pb.Variant{Name: variant.Name, Url: variant.URL, Weight: int32(variant.Weight)})
+	}
+	return converted
+}
+
+func optionalInt(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+func optionalInt32(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	i := int32(*v)
+	return &i
+}
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
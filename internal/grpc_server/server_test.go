@@ -0,0 +1,332 @@
+package grpc_server
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/grpc_server/pb"
+	"url_shortener/internal/logger"
+	"url_shortener/internal/services"
+	"url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func setupClient(t *testing.T, urlService services.UrlService) pb.ShortenerClient {
+	cfg := config.Config{HttpServer: config.HttpServer{User: "user", Password: "secret"}}
+
+	lis := bufconn.Listen(1 << 20)
+	server := New(urlService, cfg, "https://sho.rt/url", slog.Default())
+	go server.Serve(lis)
+	t.Cleanup(server.GracefulStop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewShortenerClient(conn)
+}
+
+func authContext(user string, password string) context.Context {
+	token := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+token)
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		expectedCode codes.Code
+	}{
+		{
+			name:         "missing credentials",
+			ctx:          context.Background(),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "wrong password",
+			ctx:          authContext("user", "wrong"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "valid credentials",
+			ctx:          authContext("user", "secret"),
+			expectedCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			mockService.On("TagStats", mock.Anything).Return([]storage.TagStats{}, nil).Maybe()
+
+			_, err := setupClient(t, mockService).Stats(tt.ctx, &pb.StatsRequest{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		request      *pb.CreateRequest
+		expectedCode codes.Code
+		expectedLink *pb.Link
+		mockSetup    func(*mocks.UrlService)
+	}{
+		{
+			name:         "successful create",
+			request:      &pb.CreateRequest{Url: "https://example.com", Alias: "test", MaxVisits: int32Ptr(3), ExpiresAt: timestamppb.New(expiresAt), Tags: []string{"promo"}},
+			expectedCode: codes.OK,
+			expectedLink: &pb.Link{Alias: "test", ShortUrl: "https://sho.rt/url/test", Url: "https://example.com", Owner: "user", CreatedAt: timestamppb.New(createdAt),
+				ExpiresAt: timestamppb.New(expiresAt), RedirectType: 302, MaxVisits: int32Ptr(3), Remaining: int32Ptr(3), Tags: []string{"promo"}},
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", MaxVisits: intPtr(3), ExpiresAt: &expiresAt, Tags: []string{"promo"}, Owner: "user"}, false, storage.Actor{User: "user"}).
+					Return(storage.URL{URL: "https://example.com", Alias: "test", MaxVisits: intPtr(3), ExpiresAt: &expiresAt, Tags: []string{"promo"}, Owner: "user",
+						CreatedAt: createdAt, RedirectType: 302}, nil)
+			},
+		},
+		{
+			name:         "reuse existing link",
+			request:      &pb.CreateRequest{Url: "https://example.com", ReuseExisting: true},
+			expectedCode: codes.OK,
+			expectedLink: &pb.Link{Alias: "k3Xy9Zp", ShortUrl: "https://sho.rt/url/k3Xy9Zp", Url: "https://example.com", Owner: "user", CreatedAt: timestamppb.New(createdAt), RedirectType: 302},
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Owner: "user"}, true, storage.Actor{User: "user"}).
+					Return(storage.URL{URL: "https://example.com", Alias: "k3Xy9Zp", Owner: "user", CreatedAt: createdAt, RedirectType: 302, Reused: true}, nil)
+			},
+		},
+		{
+			name:         "missing url",
+			request:      &pb.CreateRequest{Alias: "test"},
+			expectedCode: codes.InvalidArgument,
+			mockSetup:    func(m *mocks.UrlService) {},
+		},
+		{
+			name:         "invalid settings",
+			request:      &pb.CreateRequest{Url: "https://example.com", RedirectType: 303},
+			expectedCode: codes.InvalidArgument,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", RedirectType: 303, Owner: "user"}, false, storage.Actor{User: "user"}).Return(storage.URL{}, services.ErrInvalidInput)
+			},
+		},
+		{
+			name:         "alias already exists",
+			request:      &pb.CreateRequest{Url: "https://example.com", Alias: "test"},
+			expectedCode: codes.AlreadyExists,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", Owner: "user"}, false, storage.Actor{User: "user"}).Return(storage.URL{}, services.ErrURLAlreadyExists)
+			},
+		},
+		{
+			name:         "reputation unavailable",
+			request:      &pb.CreateRequest{Url: "https://example.com", Alias: "test"},
+			expectedCode: codes.Unavailable,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", Owner: "user"}, false, storage.Actor{User: "user"}).Return(storage.URL{}, services.ErrReputationUnavailable)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			link, err := setupClient(t, mockService).Create(authContext("user", "secret"), tt.request)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedLink != nil {
+				assert.True(t, proto.Equal(tt.expectedLink, link), "expected %v, got %v", tt.expectedLink, link)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("GetURLInfo", mock.Anything, "go.brand.com", "test").Return(storage.URL{Alias: "test", URL: "https://brand.com", Domain: "go.brand.com", Visits: 2}, nil)
+	mockService.On("GetURLInfo", mock.Anything, "", "missing").Return(storage.URL{}, services.ErrURLNotFound)
+	client := setupClient(t, mockService)
+
+	link, err := client.Get(authContext("user", "secret"), &pb.GetRequest{Alias: "test", Domain: "go.brand.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.brand.com/url/test", link.GetShortUrl())
+	assert.Equal(t, int32(2), link.GetVisits())
+	assert.Nil(t, link.MaxVisits)
+
+	_, err = client.Get(authContext("user", "secret"), &pb.GetRequest{Alias: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Get(authContext("user", "secret"), &pb.GetRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	updated := storage.URL{URL: "https://example.org", Alias: "test", RedirectType: 301, GeoTargets: map[string]string{"DE": "https://example.org/de"},
+		DeviceRules: []storage.DeviceRule{{OS: "android", URL: "https://play.google.com/store/apps/details?id=org.example"}}}
+	mockService.On("UpdateURL", mock.Anything, updated, storage.Actor{User: "user"}).Return(updated, nil)
+	mockService.On("UpdateURL", mock.Anything, storage.URL{URL: "https://example.org", Alias: "missing"}, storage.Actor{User: "user"}).Return(storage.URL{}, services.ErrURLNotFound)
+	client := setupClient(t, mockService)
+
+	link, err := client.Update(authContext("user", "secret"), &pb.UpdateRequest{Alias: "test", Url: "https://example.org", RedirectType: 301,
+		GeoTargets:  map[string]string{"DE": "https://example.org/de"},
+		DeviceRules: []*pb.DeviceRule{{Os: "android", Url: "https://play.google.com/store/apps/details?id=org.example"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", link.GetUrl())
+	assert.Equal(t, int32(301), link.GetRedirectType())
+	assert.Equal(t, map[string]string{"DE": "https://example.org/de"}, link.GetGeoTargets())
+	require.Len(t, link.GetDeviceRules(), 1)
+	assert.Equal(t, "android", link.GetDeviceRules()[0].GetOs())
+
+	_, err = client.Update(authContext("user", "secret"), &pb.UpdateRequest{Alias: "missing", Url: "https://example.org"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("DeleteURL", mock.Anything, "", "test", storage.Actor{User: "user"}).Return(nil)
+	client := setupClient(t, mockService)
+
+	_, err := client.Delete(authContext("user", "secret"), &pb.DeleteRequest{Alias: "test"})
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+func TestBlock(t *testing.T) {
+	blockedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockService := new(mocks.UrlService)
+	mockService.On("BlockURL", mock.Anything, "", "test", "phishing", storage.Actor{User: "user"}).
+		Return(storage.URL{Alias: "test", URL: "https://example.com", BlockedAt: &blockedAt, BlockReason: "phishing"}, nil)
+	mockService.On("UnblockURL", mock.Anything, "", "missing", storage.Actor{User: "user"}).Return(storage.URL{}, services.ErrURLNotFound)
+	client := setupClient(t, mockService)
+
+	link, err := client.Block(authContext("user", "secret"), &pb.BlockRequest{Alias: "test", Reason: "phishing"})
+	require.NoError(t, err)
+	assert.Equal(t, blockedAt, link.GetBlockedAt().AsTime())
+	assert.Equal(t, "phishing", link.GetBlockReason())
+
+	_, err = client.Unblock(authContext("user", "secret"), &pb.UnblockRequest{Alias: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Block(authContext("user", "secret"), &pb.BlockRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestList(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Tag: "promo", Limit: 10}).
+		Return([]storage.URL{{Alias: "a", URL: "https://example.com/a"}, {Alias: "b", URL: "https://example.com/b"}}, nil)
+	mockService.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Limit: 5000}).Return(nil, services.ErrInvalidInput)
+	client := setupClient(t, mockService)
+
+	response, err := client.List(authContext("user", "secret"), &pb.ListRequest{Tag: "promo", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, response.GetLinks(), 2)
+	assert.Equal(t, "https://sho.rt/url/b", response.GetLinks()[1].GetShortUrl())
+
+	_, err = client.List(authContext("user", "secret"), &pb.ListRequest{Limit: 5000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestStats(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("TagStats", mock.Anything).Return([]storage.TagStats{{Tag: "promo", Links: 2, Visits: 7}}, nil)
+
+	response, err := setupClient(t, mockService).Stats(authContext("user", "secret"), &pb.StatsRequest{})
+	require.NoError(t, err)
+	require.Len(t, response.GetTags(), 1)
+	assert.Equal(t, "promo", response.GetTags()[0].GetTag())
+	assert.Equal(t, int32(2), response.GetTags()[0].GetLinks())
+	assert.Equal(t, int32(7), response.GetTags()[0].GetVisits())
+	mockService.AssertExpectations(t)
+}
+
+func TestLinkStats(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	link := storage.URL{Alias: "test", Visits: 4, Variants: []storage.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 1},
+		{Name: "b", URL: "https://example.com/b", Weight: 1},
+	}}
+	mockService.On("LinkStats", mock.Anything, "", "test").Return(storage.LinkStats{Link: link, VariantClicks: map[string]int{"a": 1, "b": 3}}, nil)
+	mockService.On("LinkStats", mock.Anything, "", "missing").Return(storage.LinkStats{}, services.ErrURLNotFound)
+	client := setupClient(t, mockService)
+
+	response, err := client.LinkStats(authContext("user", "secret"), &pb.LinkStatsRequest{Alias: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), response.GetVisits())
+	require.Len(t, response.GetVariants(), 2)
+	assert.Equal(t, "b", response.GetVariants()[1].GetName())
+	assert.Equal(t, int32(3), response.GetVariants()[1].GetClicks())
+
+	_, err = client.LinkStats(authContext("user", "secret"), &pb.LinkStatsRequest{Alias: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestRequestID(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("TagStats", mock.MatchedBy(func(ctx context.Context) bool {
+		return logger.RequestID(ctx) == "abc-123"
+	})).Return([]storage.TagStats{}, nil)
+
+	ctx := metadata.AppendToOutgoingContext(authContext("user", "secret"), "x-request-id", "abc-123")
+	var header metadata.MD
+	_, err := setupClient(t, mockService).Stats(ctx, &pb.StatsRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc-123"}, header.Get("x-request-id"))
+	mockService.AssertExpectations(t)
+}
+
+func TestDryRunRules(t *testing.T) {
+	at := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	mockService := new(mocks.UrlService)
+	mockService.On("MatchRule", mock.Anything, "", "test", services.Visitor{AcceptLanguage: "de", Query: url.Values{"ref": {"partner"}}}, at).
+		Return(services.RuleMatch{Matched: true, Index: 2, Rule: storage.RedirectRule{URL: "https://example.de"}}, nil)
+	client := setupClient(t, mockService)
+
+	response, err := client.DryRunRules(authContext("user", "secret"), &pb.DryRunRulesRequest{Alias: "test", Time: timestamppb.New(at), AcceptLanguage: "de", Query: "ref=partner"})
+	require.NoError(t, err)
+	assert.True(t, response.GetMatched())
+	assert.Equal(t, int32(2), response.GetRule())
+	assert.Equal(t, "https://example.de", response.GetUrl())
+
+	_, err = client.DryRunRules(authContext("user", "secret"), &pb.DryRunRulesRequest{Alias: "test", Query: "a=%zz"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+	"url_shortener/internal/services"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController interface {
+	ListAudit(ctx *gin.Context)
+}
+
+type auditController struct {
+	auditService services.AuditService
+	log          *slog.Logger
+}
+
+type AuditEntryResponse struct {
+	ID       int64  `json:"id"`
+	Action   string `json:"action"`
+	Actor    string `json:"actor"`
+	ClientIP string `json:"clientIp"`
+	Target   string `json:"target"`
+	// Before and After are null when the target was created or deleted.
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type AuditListResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+}
+
+func NewAuditController(auditService services.AuditService, logger *slog.Logger) *auditController {
+	return &auditController{auditService: auditService, log: logger}
+}
+
+// actor is the authenticated user and client address of the request, as
+// recorded in the audit log.
+func actor(ctx *gin.Context) storage.Actor {
+	return storage.Actor{User: ctx.GetString(gin.AuthUserKey), IP: ctx.ClientIP()}
+}
+
+func (c *auditController) ListAudit(ctx *gin.Context) {
+	const fn = "controllers.audit_controller.ListAudit"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	filter := storage.AuditFilter{
+		Actor:  ctx.Query("actor"),
+		Action: ctx.Query("action"),
+		Target: ctx.Query("target"),
+	}
+
+	var err error
+	if filter.Since, err = queryTime(ctx, "since"); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "invalid since", slog.String("since", ctx.Query("since")))
+		ctx.JSON(400, gin.H{"error": "since must be an RFC 3339 time"})
+		return
+	}
+	if filter.Until, err = queryTime(ctx, "until"); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "invalid until", slog.String("until", ctx.Query("until")))
+		ctx.JSON(400, gin.H{"error": "until must be an RFC 3339 time"})
+		return
+	}
+	if limit, ok := ctx.GetQuery("limit"); ok {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid limit", slog.String("limit", limit))
+			ctx.JSON(400, gin.H{"error": "limit must be a number"})
+			return
+		}
+	}
+	if offset, ok := ctx.GetQuery("offset"); ok {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid offset", slog.String("offset", offset))
+			ctx.JSON(400, gin.H{"error": "offset must be a number"})
+			return
+		}
+	}
+
+	entries, err := c.auditService.ListAudit(ctx.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid list parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to list the audit log", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := AuditListResponse{Entries: make([]AuditEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, AuditEntryResponse{
+			ID:        entry.ID,
+			Action:    entry.Action,
+			Actor:     entry.Actor.User,
+			ClientIP:  entry.Actor.IP,
+			Target:    entry.Target,
+			Before:    entry.Before,
+			After:     entry.After,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	ctx.JSON(200, response)
+}
+
+// queryTime parses an optional RFC 3339 query parameter.
+func queryTime(ctx *gin.Context, name string) (*time.Time, error) {
+	value, ok := ctx.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditController(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.AuditService)
+	}{
+		{
+			name:           "list filtered entries",
+			path:           "/audit?actor=user&action=link.delete&target=go.brand.com/test&since=2025-01-02T00:00:00Z&limit=10&offset=20",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"entries":[{"id":3,"action":"link.delete","actor":"user","clientIp":"192.0.2.1","target":"go.brand.com/test","before":{"alias":"test"},"after":null,"createdAt":"2025-01-02T03:04:05Z"}]}`,
+			mockSetup: func(m *mocks.AuditService) {
+				since := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+				m.On("ListAudit", mock.Anything, storage.AuditFilter{Actor: "user", Action: "link.delete", Target: "go.brand.com/test", Since: &since, Limit: 10, Offset: 20}).
+					Return([]storage.AuditEntry{{
+						ID:        3,
+						Action:    storage.AuditLinkDelete,
+						Actor:     storage.Actor{User: "user", IP: "192.0.2.1"},
+						Target:    "go.brand.com/test",
+						Before:    json.RawMessage(`{"alias":"test"}`),
+						CreatedAt: createdAt,
+					}}, nil)
+			},
+		},
+		{
+			name:           "invalid until",
+			path:           "/audit?until=tomorrow",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"until must be an RFC 3339 time"}`,
+			mockSetup:      func(m *mocks.AuditService) {},
+		},
+		{
+			name:           "invalid offset",
+			path:           "/audit?offset=last",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"offset must be a number"}`,
+			mockSetup:      func(m *mocks.AuditService) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.AuditService)
+			tt.mockSetup(mockService)
+
+			router := gin.Default()
+			router.GET("/audit", NewAuditController(mockService, slog.Default()).ListAudit)
+
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestActor(t *testing.T) {
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) { ctx.Set(gin.AuthUserKey, "user") })
+
+	var got storage.Actor
+	router.GET("/", func(ctx *gin.Context) { got = actor(ctx) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, storage.Actor{User: "user", IP: "192.0.2.1"}, got)
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+	"url_shortener/internal/services"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DomainController interface {
+	SaveDomain(ctx *gin.Context)
+	ListDomains(ctx *gin.Context)
+	DeleteDomain(ctx *gin.Context)
+}
+
+type domainController struct {
+	domainService services.DomainService
+	log           *slog.Logger
+}
+
+type DomainRequest struct {
+	Name string `json:"name"`
+}
+
+type DomainResponse struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DomainListResponse struct {
+	Domains []DomainResponse `json:"domains"`
+}
+
+func NewDomainController(domainService services.DomainService, logger *slog.Logger) *domainController {
+	return &domainController{domainService: domainService, log: logger}
+}
+
+func (c *domainController) SaveDomain(ctx *gin.Context) {
+	const fn = "controllers.domain_controller.SaveDomain"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	var requestJson DomainRequest
+	if err := ctx.BindJSON(&requestJson); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := c.domainService.SaveDomain(ctx.Request.Context(), requestJson.Name, actor(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid domain", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrDomainAlreadyExists) {
+			log.ErrorContext(ctx.Request.Context(), "domain already exists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "server error during saving the domain", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	ctx.JSON(201, domainResponse(domain))
+}
+
+func (c *domainController) ListDomains(ctx *gin.Context) {
+	const fn = "controllers.domain_controller.ListDomains"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	domains, err := c.domainService.ListDomains(ctx.Request.Context())
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to list domains", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := DomainListResponse{Domains: make([]DomainResponse, 0, len(domains))}
+	for _, domain := range domains {
+		response.Domains = append(response.Domains, domainResponse(domain))
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *domainController) DeleteDomain(ctx *gin.Context) {
+	const fn = "controllers.domain_controller.DeleteDomain"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	name := ctx.Param("name")
+	if err := c.domainService.DeleteDomain(ctx.Request.Context(), name, actor(ctx)); err != nil {
+		if errors.Is(err, services.ErrDomainNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "domain not found", slog.String("domain", name))
+			ctx.JSON(404, gin.H{"error": "domain not found"})
+			return
+		}
+		if errors.Is(err, services.ErrDomainInUse) {
+			log.ErrorContext(ctx.Request.Context(), "domain still has links", slog.String("domain", name))
+			ctx.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "error trying to delete the domain", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"status": "OK",
+	})
+}
+
+func domainResponse(domain storage.Domain) DomainResponse {
+	return DomainResponse{Name: domain.Name, CreatedAt: domain.CreatedAt}
+}
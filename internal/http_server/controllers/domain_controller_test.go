@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url_shortener/internal/services"
+	"url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupDomainRouter(controller DomainController) *gin.Engine {
+	router := gin.Default()
+	router.GET("/domains", controller.ListDomains)
+	router.POST("/domains", controller.SaveDomain)
+	router.DELETE("/domains/:name", controller.DeleteDomain)
+	return router
+}
+
+func TestDomainController(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.DomainService)
+	}{
+		{
+			name:           "save domain",
+			method:         "POST",
+			path:           "/domains",
+			requestBody:    `{"name": "go.brand.com"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"name":"go.brand.com","createdAt":"2025-01-02T03:04:05Z"}`,
+			mockSetup: func(m *mocks.DomainService) {
+				m.On("SaveDomain", mock.Anything, "go.brand.com", storage.Actor{}).Return(storage.Domain{Name: "go.brand.com", CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "save existing domain",
+			method:         "POST",
+			path:           "/domains",
+			requestBody:    `{"name": "go.brand.com"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"error":"domain already exists"}`,
+			mockSetup: func(m *mocks.DomainService) {
+				m.On("SaveDomain", mock.Anything, "go.brand.com", storage.Actor{}).Return(storage.Domain{}, services.ErrDomainAlreadyExists)
+			},
+		},
+		{
+			name:           "list domains",
+			method:         "GET",
+			path:           "/domains",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"domains":[{"name":"go.brand.com","createdAt":"2025-01-02T03:04:05Z"}]}`,
+			mockSetup: func(m *mocks.DomainService) {
+				m.On("ListDomains", mock.Anything).Return([]storage.Domain{{Name: "go.brand.com", CreatedAt: createdAt}}, nil)
+			},
+		},
+		{
+			name:           "delete domain with links",
+			method:         "DELETE",
+			path:           "/domains/go.brand.com",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"error":"domain still has links"}`,
+			mockSetup: func(m *mocks.DomainService) {
+				m.On("DeleteDomain", mock.Anything, "go.brand.com", storage.Actor{}).Return(services.ErrDomainInUse)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.DomainService)
+			tt.mockSetup(mockService)
+
+			router := setupDomainRouter(NewDomainController(mockService, slog.Default()))
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
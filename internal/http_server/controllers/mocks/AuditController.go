@@ -0,0 +1,32 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditController is an autogenerated mock type for the AuditController type
+type AuditController struct {
+	mock.Mock
+}
+
+// ListAudit provides a mock function with given fields: ctx
+func (_m *AuditController) ListAudit(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// NewAuditController creates a new instance of AuditController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditController {
+	mock := &AuditController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,42 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// DomainController is an autogenerated mock type for the DomainController type
+type DomainController struct {
+	mock.Mock
+}
+
+// DeleteDomain provides a mock function with given fields: ctx
+func (_m *DomainController) DeleteDomain(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ListDomains provides a mock function with given fields: ctx
+func (_m *DomainController) ListDomains(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// SaveDomain provides a mock function with given fields: ctx
+func (_m *DomainController) SaveDomain(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// NewDomainController creates a new instance of DomainController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDomainController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DomainController {
+	mock := &DomainController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -12,21 +12,66 @@ type UrlContoller struct {
 	mock.Mock
 }
 
+// BlockURL provides a mock function with given fields: ctx
+func (_m *UrlContoller) BlockURL(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // DeleteURL provides a mock function with given fields: ctx
 func (_m *UrlContoller) DeleteURL(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// DryRunRules provides a mock function with given fields: ctx
+func (_m *UrlContoller) DryRunRules(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetLinkStats provides a mock function with given fields: ctx
+func (_m *UrlContoller) GetLinkStats(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetQRCode provides a mock function with given fields: ctx
+func (_m *UrlContoller) GetQRCode(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// GetTagStats provides a mock function with given fields: ctx
+func (_m *UrlContoller) GetTagStats(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // GetURL provides a mock function with given fields: ctx
 func (_m *UrlContoller) GetURL(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// GetURLInfo provides a mock function with given fields: ctx
+func (_m *UrlContoller) GetURLInfo(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ListURLs provides a mock function with given fields: ctx
+func (_m *UrlContoller) ListURLs(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // SaveURL provides a mock function with given fields: ctx
 func (_m *UrlContoller) SaveURL(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// UnblockURL provides a mock function with given fields: ctx
+func (_m *UrlContoller) UnblockURL(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// UpdateURL provides a mock function with given fields: ctx
+func (_m *UrlContoller) UpdateURL(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // NewUrlContoller creates a new instance of UrlContoller. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUrlContoller(t interface {
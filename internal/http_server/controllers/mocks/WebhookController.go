@@ -0,0 +1,47 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookController is an autogenerated mock type for the WebhookController type
+type WebhookController struct {
+	mock.Mock
+}
+
+// DeleteSubscription provides a mock function with given fields: ctx
+func (_m *WebhookController) DeleteSubscription(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ListDeliveries provides a mock function with given fields: ctx
+func (_m *WebhookController) ListDeliveries(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ListSubscriptions provides a mock function with given fields: ctx
+func (_m *WebhookController) ListSubscriptions(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// SaveSubscription provides a mock function with given fields: ctx
+func (_m *WebhookController) SaveSubscription(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// NewWebhookController creates a new instance of WebhookController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookController {
+	mock := &WebhookController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
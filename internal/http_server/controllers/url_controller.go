@@ -1,28 +1,131 @@
 package controllers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"url_shortener/internal/qr"
 	"url_shortener/internal/services"
+	"url_shortener/internal/storage"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
 )
 
 type UrlContoller interface {
 	SaveURL(ctx *gin.Context)
 	GetURL(ctx *gin.Context)
+	GetURLInfo(ctx *gin.Context)
+	GetQRCode(ctx *gin.Context)
+	UpdateURL(ctx *gin.Context)
+	ListURLs(ctx *gin.Context)
+	GetTagStats(ctx *gin.Context)
+	GetLinkStats(ctx *gin.Context)
+	DryRunRules(ctx *gin.Context)
+	BlockURL(ctx *gin.Context)
+	UnblockURL(ctx *gin.Context)
 	DeleteURL(ctx *gin.Context)
 }
 
 type urlContoller struct {
-	urlService services.UrlService
-	log        *slog.Logger
+	urlService   services.UrlService
+	shortURLBase url.URL
+	log          *slog.Logger
 }
 
 type Request struct {
 	URLToSave string `json:"urlToSave"`
 	Alias     string `json:"alias"`
+	MaxVisits *int   `json:"maxVisits"`
+	// Interstitial forces a warning page before every redirect.
+	Interstitial bool       `json:"interstitial"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	RedirectType int        `json:"redirectType"`
+	Tags         []string   `json:"tags"`
+	Folder       string     `json:"folder"`
+	// Domain is the custom hostname of the link, empty for the default domain.
+	Domain string `json:"domain"`
+	// GeoTargets maps country codes to the destinations of their visitors.
+	GeoTargets map[string]string `json:"geoTargets"`
+	// Rules are tried in order before any other targeting, the first
+	// matching rule wins.
+	Rules []RedirectRule `json:"rules"`
+	// DeviceRules are tried in order, the first matching rule wins.
+	DeviceRules []DeviceRule `json:"deviceRules"`
+	// Variants split the visits between weighted destinations.
+	Variants []Variant `json:"variants"`
+	// StickyVariants keeps returning visitors on the variant they got first.
+	StickyVariants bool `json:"stickyVariants"`
+	// Prefix forwards the path after the alias to the destination.
+	Prefix bool `json:"prefix"`
+	// QueryPassthrough forwards the query of the short link: drop, merge or
+	// override.
+	QueryPassthrough string `json:"queryPassthrough"`
+	// UTM are utm_* parameters added to the destination.
+	UTM map[string]string `json:"utm"`
+	// ReuseExisting returns the link the user already has for the
+	// destination instead of creating one when the alias is generated.
+	ReuseExisting bool `json:"reuseExisting"`
+}
+
+// Variant is a named destination served to a share of the visits
+// proportional to its weight.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// variantCookie remembers the variant served to a visitor of a sticky link.
+const (
+	variantCookie       = "variant"
+	variantCookieMaxAge = 30 * 24 * 60 * 60
+)
+
+// RedirectRule sends the visits matching all of its set conditions to url.
+type RedirectRule struct {
+	Days      []string          `json:"days"`
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Timezone  string            `json:"timezone"`
+	After     *time.Time        `json:"after"`
+	Before    *time.Time        `json:"before"`
+	Languages []string          `json:"languages"`
+	Query     map[string]string `json:"query"`
+	URL       string            `json:"url"`
+}
+
+// DryRunRequest describes a synthetic visit to evaluate the rules against.
+type DryRunRequest struct {
+	// Time is when the visit happens, now when omitted.
+	Time           *time.Time `json:"time"`
+	AcceptLanguage string     `json:"acceptLanguage"`
+	// Query is the query string of the short link, such as "utm_source=mail".
+	Query string `json:"query"`
+}
+
+// DryRunResponse reports the rule a visit would match, rule and url are null
+// when the visit falls through to the other targeting of the link.
+type DryRunResponse struct {
+	Matched bool    `json:"matched"`
+	Rule    *int    `json:"rule"`
+	URL     *string `json:"url"`
+}
+
+// DeviceRule sends the visitors with the os and device class to url.
+type DeviceRule struct {
+	OS     string `json:"os"`
+	Device string `json:"device"`
+	URL    string `json:"url"`
 }
 
 type Response struct {
@@ -30,8 +133,95 @@ type Response struct {
 	Error  string `json:"error,omitempty"`
 }
 
-func NewURLController(urlService services.UrlService, logger *slog.Logger) *urlContoller {
-	return &urlContoller{urlService: urlService, log: logger}
+// LinkResponse is the link resource returned by create, update and info.
+type LinkResponse struct {
+	Alias            string            `json:"alias"`
+	Domain           string            `json:"domain"`
+	ShortURL         string            `json:"shortURL"`
+	URL              string            `json:"url"`
+	Owner            string            `json:"owner"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        *time.Time        `json:"updatedAt"`
+	ExpiresAt        *time.Time        `json:"expiresAt"`
+	RedirectType     int               `json:"redirectType"`
+	MaxVisits        *int              `json:"maxVisits"`
+	Visits           int               `json:"visits"`
+	Remaining        *int              `json:"remaining"`
+	LastVisitAt      *time.Time        `json:"lastVisitAt"`
+	Interstitial     bool              `json:"interstitial"`
+	Tags             []string          `json:"tags"`
+	Folder           string            `json:"folder"`
+	GeoTargets       map[string]string `json:"geoTargets"`
+	Rules            []RedirectRule    `json:"rules"`
+	DeviceRules      []DeviceRule      `json:"deviceRules"`
+	Variants         []Variant         `json:"variants"`
+	StickyVariants   bool              `json:"stickyVariants"`
+	QueryPassthrough string            `json:"queryPassthrough"`
+	UTM              map[string]string `json:"utm"`
+	Prefix           bool              `json:"prefix"`
+	BlockedAt        *time.Time        `json:"blockedAt"`
+	BlockReason      string            `json:"blockReason"`
+}
+
+// BlockRequest gives the reason shown on the warning page of a blocked link.
+type BlockRequest struct {
+	Reason string `json:"reason"`
+}
+
+// BlockedResponse is the warning page served instead of the redirect of a
+// blocked link.
+type BlockedResponse struct {
+	Error  string `json:"error"`
+	Alias  string `json:"alias"`
+	Reason string `json:"reason"`
+}
+
+type ListResponse struct {
+	Links []LinkResponse `json:"links"`
+}
+
+type TagStatsResponse struct {
+	Tags []TagStats `json:"tags"`
+}
+
+type TagStats struct {
+	Tag    string `json:"tag"`
+	Links  int    `json:"links"`
+	Visits int    `json:"visits"`
+}
+
+// LinkStatsResponse reports the visits of a link and of each variant.
+type LinkStatsResponse struct {
+	Alias    string         `json:"alias"`
+	Domain   string         `json:"domain"`
+	Visits   int            `json:"visits"`
+	Variants []VariantStats `json:"variants"`
+}
+
+type VariantStats struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Clicks int    `json:"clicks"`
+}
+
+type PreviewResponse struct {
+	Alias       string    `json:"alias"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Visits      int       `json:"visits"`
+	Warning     bool      `json:"warning"`
+	ContinueURL string    `json:"continueURL"`
+}
+
+// NewURLController creates the controller. shortURLBase is the public URL
+// aliases are appended to, such as "https://sho.rt/url".
+func NewURLController(urlService services.UrlService, shortURLBase string, logger *slog.Logger) *urlContoller {
+	base, err := url.Parse(strings.TrimSuffix(shortURLBase, "/"))
+	if err != nil {
+		panic(fmt.Sprintf("invalid short url base %q: %s", shortURLBase, err))
+	}
+	return &urlContoller{urlService: urlService, shortURLBase: *base, log: logger}
 }
 
 func (c *urlContoller) SaveURL(ctx *gin.Context) {
@@ -43,29 +233,47 @@ func (c *urlContoller) SaveURL(ctx *gin.Context) {
 
 	var requestJson Request
 	if err := ctx.BindJSON(&requestJson); err != nil {
-		log.Error("failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		log.ErrorContext(ctx.Request.Context(), "failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		ctx.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
 	if requestJson.URLToSave == "" {
-		log.Error("missing required field", slog.String("field", "urlToSave"))
+		log.ErrorContext(ctx.Request.Context(), "missing required field", slog.String("field", "urlToSave"))
 		ctx.JSON(400, gin.H{"error": "urlToSave is required"})
 		return
 	}
 
-	if err := c.urlService.SaveURL(requestJson.URLToSave, requestJson.Alias); err != nil {
+	link := requestJson.toURL(requestJson.Alias)
+	link.Owner = ctx.GetString(gin.AuthUserKey)
+
+	link, err := c.urlService.SaveURL(ctx.Request.Context(), link, requestJson.ReuseExisting, actor(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid link parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, services.ErrURLAlreadyExists) {
-			log.Error("data already exists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			log.ErrorContext(ctx.Request.Context(), "data already exists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 			ctx.JSON(409, gin.H{"error": err.Error()})
 			return
 		}
-		log.Error("server error during saving the URL", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		if errors.Is(err, services.ErrReputationUnavailable) {
+			log.ErrorContext(ctx.Request.Context(), "reputation of the destination cannot be checked", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(503, gin.H{"error": services.ErrReputationUnavailable.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "server error during saving the URL", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		ctx.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(201, gin.H{"status": "OK"})
+	if link.Reused {
+		ctx.JSON(200, c.linkResponse(link))
+		return
+	}
+	ctx.JSON(201, c.linkResponse(link))
 }
 
 func (c *urlContoller) GetURL(ctx *gin.Context) {
@@ -75,26 +283,667 @@ func (c *urlContoller) GetURL(ctx *gin.Context) {
 		slog.String("fn", fn),
 	)
 
+	// the catch-all route of prefix links sets path to the rest after the alias
+	alias := ctx.Param("alias") + ctx.Param("path")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	domain, err := c.urlService.ResolveDomain(ctx.Request.Context(), ctx.Request.Host)
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to resolve domain", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	// "/url/:alias+" and "?preview=1" show where the link goes instead of going there
+	if strings.HasSuffix(alias, "+") || ctx.Query("preview") == "1" {
+		c.renderPreview(ctx, log, domain, strings.TrimSuffix(alias, "+"), false)
+		return
+	}
+
+	visitor := services.Visitor{
+		IP:             ctx.ClientIP(),
+		UserAgent:      ctx.Request.UserAgent(),
+		AcceptLanguage: ctx.GetHeader("Accept-Language"),
+		Query:          forwardedQuery(ctx),
+	}
+	if variant, err := ctx.Cookie(variantCookie); err == nil {
+		visitor.Variant = variant
+	}
+
+	link, err := c.urlService.GetURL(ctx.Request.Context(), domain, alias, ctx.Query("confirm") == "1", visitor)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNeedsPreview) {
+			c.renderPreview(ctx, log, domain, alias, true)
+			return
+		}
+		if errors.Is(err, services.ErrURLBlocked) {
+			c.renderPreview(ctx, log, domain, alias, false)
+			return
+		}
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		if errors.Is(err, services.ErrURLGone) {
+			log.InfoContext(ctx.Request.Context(), "URL visits limit reached", slog.String("alias", alias))
+			ctx.JSON(410, gin.H{"error": "URL is no longer available"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to retrieve URL", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	if link.StickyVariants && link.Variant != "" {
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:     variantCookie,
+			Value:    link.Variant,
+			Path:     ctx.Request.URL.Path,
+			MaxAge:   variantCookieMaxAge,
+			Secure:   ctx.Request.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	// browsers keep permanent redirects for good and would skip the targeting
+	// and limits of the link on the next visits
+	if link.Dynamic() {
+		ctx.Header("Cache-Control", "no-store")
+	}
+	ctx.Redirect(link.RedirectType, link.URL)
+}
+
+func (c *urlContoller) GetURLInfo(ctx *gin.Context) {
+	const fn = "controllers.url_controller.GetURLInfo"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	alias := ctx.Param("alias")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	link, err := c.urlService.GetURLInfo(ctx.Request.Context(), ctx.Query("domain"), alias)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to retrieve URL info", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	ctx.JSON(200, c.linkResponse(link))
+}
+
+func (c *urlContoller) UpdateURL(ctx *gin.Context) {
+	const fn = "controllers.url_controller.UpdateURL"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	alias := ctx.Param("alias")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	var requestJson Request
+	if err := ctx.BindJSON(&requestJson); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestJson.URLToSave == "" {
+		log.ErrorContext(ctx.Request.Context(), "missing required field", slog.String("field", "urlToSave"))
+		ctx.JSON(400, gin.H{"error": "urlToSave is required"})
+		return
+	}
+
+	// the link is selected by the query, a domain in the body would move it
+	domain := ctx.Query("domain")
+	if requestJson.Domain != "" && requestJson.Domain != domain {
+		log.ErrorContext(ctx.Request.Context(), "domain of the body differs from the query", slog.String("domain", requestJson.Domain))
+		ctx.JSON(400, gin.H{"error": "domain must match the domain query parameter"})
+		return
+	}
+
+	link := requestJson.toURL(alias)
+	link.Domain = domain
+
+	link, err := c.urlService.UpdateURL(ctx.Request.Context(), link, actor(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid link parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		if errors.Is(err, services.ErrReputationUnavailable) {
+			log.ErrorContext(ctx.Request.Context(), "reputation of the destination cannot be checked", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(503, gin.H{"error": services.ErrReputationUnavailable.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "server error during updating the URL", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	ctx.JSON(200, c.linkResponse(link))
+}
+
+func (c *urlContoller) ListURLs(ctx *gin.Context) {
+	const fn = "controllers.url_controller.ListURLs"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	filter := storage.URLFilter{
+		Domain: ctx.Query("domain"),
+		Tag:    ctx.Query("tag"),
+		Folder: ctx.Query("folder"),
+	}
+	filter.AllDomains = filter.Domain == ""
+
+	var err error
+	if limit, ok := ctx.GetQuery("limit"); ok {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid limit", slog.String("limit", limit))
+			ctx.JSON(400, gin.H{"error": "limit must be a number"})
+			return
+		}
+	}
+	if offset, ok := ctx.GetQuery("offset"); ok {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid offset", slog.String("offset", offset))
+			ctx.JSON(400, gin.H{"error": "offset must be a number"})
+			return
+		}
+	}
+
+	links, err := c.urlService.ListURLs(ctx.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid list parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to list URLs", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := ListResponse{Links: make([]LinkResponse, 0, len(links))}
+	for _, link := range links {
+		response.Links = append(response.Links, c.linkResponse(link))
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *urlContoller) GetTagStats(ctx *gin.Context) {
+	const fn = "controllers.url_controller.GetTagStats"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	stats, err := c.urlService.TagStats(ctx.Request.Context())
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to get tag stats", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := TagStatsResponse{Tags: make([]TagStats, 0, len(stats))}
+	for _, st := range stats {
+		response.Tags = append(response.Tags, TagStats{Tag: st.Tag, Links: st.Links, Visits: st.Visits})
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *urlContoller) GetLinkStats(ctx *gin.Context) {
+	const fn = "controllers.url_controller.GetLinkStats"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
 	alias := ctx.Param("alias")
 	if alias == "" {
-		log.Error("alias parameter is empty")
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
 		ctx.JSON(400, gin.H{"error": "alias is required"})
 		return
 	}
 
-	originalURL, err := c.urlService.GetURL(alias)
+	stats, err := c.urlService.LinkStats(ctx.Request.Context(), ctx.Query("domain"), alias)
 	if err != nil {
 		if errors.Is(err, services.ErrURLNotFound) {
-			log.Error("URL not found", slog.String("alias", alias))
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to get link stats", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := LinkStatsResponse{
+		Alias:    stats.Link.Alias,
+		Domain:   stats.Link.Domain,
+		Visits:   stats.Link.Visits,
+		Variants: make([]VariantStats, 0, len(stats.Link.Variants)),
+	}
+	for _, variant := range stats.Link.Variants {
+		response.Variants = append(response.Variants, VariantStats{
+			Name:   variant.Name,
+			URL:    variant.URL,
+			Weight: variant.Weight,
+			Clicks: stats.VariantClicks[variant.Name],
+		})
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *urlContoller) DryRunRules(ctx *gin.Context) {
+	const fn = "controllers.url_controller.DryRunRules"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	alias := ctx.Param("alias")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	var requestJson DryRunRequest
+	if err := ctx.BindJSON(&requestJson); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := url.ParseQuery(requestJson.Query)
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "invalid query", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(400, gin.H{"error": "query must be a URL query string"})
+		return
+	}
+
+	at := time.Now()
+	if requestJson.Time != nil {
+		at = *requestJson.Time
+	}
+
+	match, err := c.urlService.MatchRule(ctx.Request.Context(), ctx.Query("domain"), alias,
+		services.Visitor{AcceptLanguage: requestJson.AcceptLanguage, Query: query}, at)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to match the rules", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := DryRunResponse{Matched: match.Matched}
+	if match.Matched {
+		response.Rule = &match.Index
+		response.URL = &match.Rule.URL
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (r Request) toURL(alias string) storage.URL {
+	var deviceRules []storage.DeviceRule
+	for _, rule := range r.DeviceRules {
+		deviceRules = append(deviceRules, storage.DeviceRule{OS: rule.OS, Device: rule.Device, URL: rule.URL})
+	}
+
+	var rules []storage.RedirectRule
+	for _, rule := range r.Rules {
+		rules = append(rules, storage.RedirectRule(rule))
+	}
+
+	var variants []storage.Variant
+	for _, variant := range r.Variants {
+		variants = append(variants, storage.Variant{Name: variant.Name, URL: variant.URL, Weight: variant.Weight})
+	}
+
+	return storage.URL{
+		URL:              r.URLToSave,
+		Alias:            alias,
+		MaxVisits:        r.MaxVisits,
+		Interstitial:     r.Interstitial,
+		ExpiresAt:        r.ExpiresAt,
+		RedirectType:     r.RedirectType,
+		Tags:             r.Tags,
+		Folder:           r.Folder,
+		Domain:           r.Domain,
+		GeoTargets:       r.GeoTargets,
+		Rules:            rules,
+		DeviceRules:      deviceRules,
+		Variants:         variants,
+		StickyVariants:   r.StickyVariants,
+		QueryPassthrough: r.QueryPassthrough,
+		UTM:              r.UTM,
+		Prefix:           r.Prefix,
+	}
+}
+
+func (c *urlContoller) linkResponse(link storage.URL) LinkResponse {
+	geoTargets := make(map[string]string, len(link.GeoTargets))
+	maps.Copy(geoTargets, link.GeoTargets)
+
+	deviceRules := make([]DeviceRule, 0, len(link.DeviceRules))
+	for _, rule := range link.DeviceRules {
+		deviceRules = append(deviceRules, DeviceRule{OS: rule.OS, Device: rule.Device, URL: rule.URL})
+	}
+
+	utm := make(map[string]string, len(link.UTM))
+	maps.Copy(utm, link.UTM)
+
+	rules := make([]RedirectRule, 0, len(link.Rules))
+	for _, rule := range link.Rules {
+		rules = append(rules, RedirectRule(rule))
+	}
+
+	variants := make([]Variant, 0, len(link.Variants))
+	for _, variant := range link.Variants {
+		variants = append(variants, Variant{Name: variant.Name, URL: variant.URL, Weight: variant.Weight})
+	}
+
+	return LinkResponse{
+		Alias:            link.Alias,
+		Domain:           link.Domain,
+		ShortURL:         link.ShortURL(c.shortURLBase),
+		URL:              link.URL,
+		Owner:            link.Owner,
+		CreatedAt:        link.CreatedAt,
+		UpdatedAt:        link.UpdatedAt,
+		ExpiresAt:        link.ExpiresAt,
+		RedirectType:     link.RedirectType,
+		MaxVisits:        link.MaxVisits,
+		Visits:           link.Visits,
+		Remaining:        link.Remaining(),
+		LastVisitAt:      link.LastVisitAt,
+		Interstitial:     link.Interstitial,
+		Tags:             append([]string{}, link.Tags...),
+		Folder:           link.Folder,
+		GeoTargets:       geoTargets,
+		Rules:            rules,
+		DeviceRules:      deviceRules,
+		Variants:         variants,
+		StickyVariants:   link.StickyVariants,
+		QueryPassthrough: link.QueryPassthrough,
+		UTM:              utm,
+		Prefix:           link.Prefix,
+		BlockedAt:        link.BlockedAt,
+		BlockReason:      link.BlockReason,
+	}
+}
+
+// previewParams control the preview and interstitial of the redirect and are
+// not forwarded to the destination.
+var previewParams = []string{"confirm", "preview"}
+
+// forwardedQuery returns the query of the short link without previewParams.
+func forwardedQuery(ctx *gin.Context) url.Values {
+	query := ctx.Request.URL.Query()
+	for _, param := range previewParams {
+		query.Del(param)
+	}
+	return query
+}
+
+// continueURL is the redirect past the preview, keeping the query of the
+// short link so it can still be forwarded.
+func continueURL(ctx *gin.Context) string {
+	query := forwardedQuery(ctx)
+	query.Set("confirm", "1")
+	return strings.TrimSuffix(ctx.Request.URL.Path, "+") + "?" + query.Encode()
+}
+
+// renderPreview responds with the link destination and stats instead of the
+// redirect, as HTML for browsers and as JSON for API clients.
+func (c *urlContoller) renderPreview(ctx *gin.Context, log *slog.Logger, domain string, alias string, warning bool) {
+	link, err := c.urlService.GetURLInfo(ctx.Request.Context(), domain, alias)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to retrieve URL info", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	// a blocked link never shows where it goes
+	if link.BlockedAt != nil {
+		blocked := BlockedResponse{Error: "URL has been blocked", Alias: link.Alias, Reason: link.BlockReason}
+		switch ctx.NegotiateFormat(binding.MIMEHTML, binding.MIMEJSON) {
+		case binding.MIMEJSON:
+			ctx.JSON(403, blocked)
+		default:
+			ctx.Render(403, render.HTML{Template: templates, Name: "blocked.html", Data: blocked})
+		}
+		return
+	}
+
+	// nor does a link that cannot be visited anymore
+	if link.Gone(time.Now()) {
+		log.InfoContext(ctx.Request.Context(), "URL is no longer available", slog.String("alias", alias))
+		ctx.JSON(410, gin.H{"error": "URL is no longer available"})
+		return
+	}
+
+	preview := PreviewResponse{
+		Alias:       link.Alias,
+		URL:         link.URL,
+		CreatedAt:   link.CreatedAt,
+		Visits:      link.Visits,
+		Warning:     warning,
+		ContinueURL: continueURL(ctx),
+	}
+
+	switch ctx.NegotiateFormat(binding.MIMEHTML, binding.MIMEJSON) {
+	case binding.MIMEJSON:
+		ctx.JSON(200, preview)
+	default:
+		ctx.Render(200, render.HTML{Template: templates, Name: "preview.html", Data: preview})
+	}
+}
+
+func (c *urlContoller) GetQRCode(ctx *gin.Context) {
+	const fn = "controllers.url_controller.GetQRCode"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	alias := ctx.Param("alias")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	opts, err := qrOptionsFromQuery(ctx)
+	if err == nil {
+		opts, err = opts.Normalize()
+	}
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "invalid qr code options", slog.String("error", err.Error()))
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := c.urlService.ResolveDomain(ctx.Request.Context(), ctx.Request.Host)
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to resolve domain", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	link, err := c.urlService.GetURLInfo(ctx.Request.Context(), domain, alias)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
+			ctx.JSON(404, gin.H{"error": "URL not found"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to retrieve URL info", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	content := link.ShortURL(c.shortURLBase)
+
+	// the image only depends on the encoded link and the render options
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%+v", content, opts)))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	image, err := qr.Encode(content, opts)
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to render qr code", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	ctx.Data(200, opts.ContentType(), image)
+}
+
+// etagMatches reports whether the If-None-Match header lists the ETag, with
+// the weak comparison of RFC 9110: W/ prefixes are ignored and * matches any.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func qrOptionsFromQuery(ctx *gin.Context) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	opts.Format = ctx.DefaultQuery("format", opts.Format)
+	opts.Level = strings.ToUpper(ctx.DefaultQuery("level", opts.Level))
+	opts.Foreground = ctx.DefaultQuery("fg", opts.Foreground)
+	opts.Background = ctx.DefaultQuery("bg", opts.Background)
+
+	var err error
+	if size, ok := ctx.GetQuery("size"); ok {
+		if opts.Size, err = strconv.Atoi(size); err != nil {
+			return opts, fmt.Errorf("%w: size must be a number", qr.ErrInvalidOptions)
+		}
+	}
+	if margin, ok := ctx.GetQuery("margin"); ok {
+		if opts.Margin, err = strconv.Atoi(margin); err != nil {
+			return opts, fmt.Errorf("%w: margin must be a number", qr.ErrInvalidOptions)
+		}
+	}
+
+	return opts, nil
+}
+
+func (c *urlContoller) BlockURL(ctx *gin.Context) {
+	const fn = "controllers.url_controller.BlockURL"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	alias := ctx.Param("alias")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	var requestJson BlockRequest
+	if err := ctx.BindJSON(&requestJson); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := c.urlService.BlockURL(ctx.Request.Context(), ctx.Query("domain"), alias, requestJson.Reason, actor(ctx))
+	c.respondBlocked(ctx, log, alias, link, err)
+}
+
+func (c *urlContoller) UnblockURL(ctx *gin.Context) {
+	const fn = "controllers.url_controller.UnblockURL"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	alias := ctx.Param("alias")
+	if alias == "" {
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
+		ctx.JSON(400, gin.H{"error": "alias is required"})
+		return
+	}
+
+	link, err := c.urlService.UnblockURL(ctx.Request.Context(), ctx.Query("domain"), alias, actor(ctx))
+	c.respondBlocked(ctx, log, alias, link, err)
+}
+
+// respondBlocked answers a block or unblock with the link or the error.
+func (c *urlContoller) respondBlocked(ctx *gin.Context, log *slog.Logger, alias string, link storage.URL, err error) {
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid block parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "URL not found", slog.String("alias", alias))
 			ctx.JSON(404, gin.H{"error": "URL not found"})
 			return
 		}
-		log.Error("failed to retrieve URL", slog.String("error", err.Error()))
+		log.ErrorContext(ctx.Request.Context(), "failed to change the block of the URL", slog.String("error", err.Error()))
 		ctx.JSON(500, gin.H{"error": "internal server error"})
 		return
 	}
 
-	ctx.Redirect(http.StatusFound, originalURL)
+	ctx.JSON(200, c.linkResponse(link))
 }
 
 func (c *urlContoller) DeleteURL(ctx *gin.Context) {
@@ -106,13 +955,13 @@ func (c *urlContoller) DeleteURL(ctx *gin.Context) {
 
 	alias := ctx.Param("alias")
 	if alias == "" {
-		log.Error("alias parameter is empty")
+		log.ErrorContext(ctx.Request.Context(), "alias parameter is empty")
 		ctx.JSON(400, gin.H{"error": "alias is required"})
 		return
 	}
 
-	if err := c.urlService.DeleteURL(alias); err != nil {
-		log.Error("error trying to delete the alias", slog.String("alias", alias))
+	if err := c.urlService.DeleteURL(ctx.Request.Context(), ctx.Query("domain"), alias, actor(ctx)); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "error trying to delete the alias", slog.String("alias", alias))
 		ctx.JSON(400, gin.H{"error": "error during deletign the url"})
 		return
 	}
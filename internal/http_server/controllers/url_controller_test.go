@@ -3,27 +3,44 @@ package controllers
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"url_shortener/internal/services"
 	"url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func setupRouter(controller UrlContoller) *gin.Engine {
 	router := gin.Default()
 	router.POST("/url", controller.SaveURL)
 	router.GET("/url/:alias", controller.GetURL)
+	router.GET("/url/:alias/info", controller.GetURLInfo)
+	router.GET("/url/:alias/qr", controller.GetQRCode)
+	router.GET("/url", controller.ListURLs)
+	router.GET("/tags", controller.GetTagStats)
+	router.GET("/url/:alias/stats", controller.GetLinkStats)
+	router.POST("/url/:alias/rules/dry-run", controller.DryRunRules)
+	router.PUT("/url/:alias", controller.UpdateURL)
 	router.DELETE("/url/:alias", controller.DeleteURL)
+	router.POST("/url/:alias/block", controller.BlockURL)
+	router.DELETE("/url/:alias/block", controller.UnblockURL)
 	return router
 }
 
 func TestSaveURL(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
 	tests := []struct {
 		name           string
 		requestBody    string
@@ -35,9 +52,62 @@ func TestSaveURL(t *testing.T) {
 			name:           "successful save",
 			requestBody:    `{"urlToSave": "https://example.com", "alias": "test"}`,
 			expectedStatus: http.StatusCreated,
-			expectedBody:   `{"status":"OK"}`,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test"}, false, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.com", Alias: "test", CreatedAt: createdAt, RedirectType: 302}, nil)
+			},
+		},
+		{
+			name:           "successful save with max visits",
+			requestBody:    `{"urlToSave": "https://example.com", "alias": "test", "maxVisits": 1}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":1,"visits":0,"remaining":1,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", MaxVisits: intPtr(1)}, false, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.com", Alias: "test", MaxVisits: intPtr(1), CreatedAt: createdAt, RedirectType: 302}, nil)
+			},
+		},
+		{
+			name:           "successful save with expiry and redirect type",
+			requestBody:    `{"urlToSave": "https://example.com", "alias": "test", "expiresAt": "2030-01-01T00:00:00Z", "redirectType": 301}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":"2030-01-01T00:00:00Z","redirectType":301,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", ExpiresAt: &expiresAt, RedirectType: 301}, false, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.com", Alias: "test", ExpiresAt: &expiresAt, CreatedAt: createdAt, RedirectType: 301}, nil)
+			},
+		},
+		{
+			name:           "successful save with tags and folder",
+			requestBody:    `{"urlToSave": "https://example.com", "alias": "test", "tags": ["Spring", "promo"], "folder": "marketing/2025"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":["promo","spring"],"folder":"marketing/2025","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", Tags: []string{"Spring", "promo"}, Folder: "marketing/2025"}, false, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.com", Alias: "test", CreatedAt: createdAt, RedirectType: 302, Tags: []string{"promo", "spring"}, Folder: "marketing/2025"}, nil)
+			},
+		},
+		{
+			name:           "successful save with targeting",
+			requestBody:    `{"urlToSave": "https://example.com", "alias": "app", "geoTargets": {"DE": "https://example.de"}, "deviceRules": [{"os": "ios", "url": "https://apps.apple.com/app/id1"}]}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"alias":"app","domain":"","shortURL":"https://sho.rt/url/app","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{"DE":"https://example.de"},"rules":[],"deviceRules":[{"os":"ios","device":"","url":"https://apps.apple.com/app/id1"}],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				link := storage.URL{URL: "https://example.com", Alias: "app", GeoTargets: map[string]string{"DE": "https://example.de"},
+					DeviceRules: []storage.DeviceRule{{OS: "ios", URL: "https://apps.apple.com/app/id1"}}}
+				m.On("SaveURL", mock.Anything, link, false, storage.Actor{}).Return(storage.URL{URL: link.URL, Alias: link.Alias, CreatedAt: createdAt, RedirectType: 302,
+					GeoTargets: link.GeoTargets, DeviceRules: link.DeviceRules}, nil)
+			},
+		},
+		{
+			name:           "non-positive max visits",
+			requestBody:    `{"urlToSave": "https://example.com", "alias": "test", "maxVisits": 0}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid input: maxVisits must be positive"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("SaveURL", "https://example.com", "test").Return(nil)
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test", MaxVisits: intPtr(0)}, false, storage.Actor{}).Return(storage.URL{}, fmt.Errorf("%w: maxVisits must be positive", services.ErrInvalidInput))
 			},
 		},
 		{
@@ -54,13 +124,33 @@ func TestSaveURL(t *testing.T) {
 			expectedBody:   `{"error":"invalid character 'i' looking for beginning of value"}`,
 			mockSetup:      func(m *mocks.UrlService) {},
 		},
+		{
+			name:           "existing link is reused",
+			requestBody:    `{"urlToSave": "https://example.com", "reuseExisting": true}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"k3Xy9Zp","domain":"","shortURL":"https://sho.rt/url/k3Xy9Zp","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com"}, true, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.com", Alias: "k3Xy9Zp", CreatedAt: createdAt, RedirectType: 302, Reused: true}, nil)
+			},
+		},
 		{
 			name:           "url already exists",
 			requestBody:    `{"urlToSave": "https://example.com", "alias": "test"}`,
 			expectedStatus: http.StatusConflict,
 			expectedBody:   `{"error":"alias already exists"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("SaveURL", "https://example.com", "test").Return(services.ErrURLAlreadyExists)
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test"}, false, storage.Actor{}).Return(storage.URL{}, services.ErrURLAlreadyExists)
+			},
+		},
+		{
+			name:           "reputation unavailable",
+			requestBody:    `{"urlToSave": "https://example.com", "alias": "test"}`,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"url reputation cannot be checked"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test"}, false, storage.Actor{}).
+					Return(storage.URL{}, fmt.Errorf("%w: remote: timeout", services.ErrReputationUnavailable))
 			},
 		},
 		{
@@ -69,7 +159,7 @@ func TestSaveURL(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"error":"internal server error"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("SaveURL", "https://example.com", "test").Return(errors.New("internal server error"))
+				m.On("SaveURL", mock.Anything, storage.URL{URL: "https://example.com", Alias: "test"}, false, storage.Actor{}).Return(storage.URL{}, errors.New("internal server error"))
 			},
 		},
 	}
@@ -81,7 +171,7 @@ func TestSaveURL(t *testing.T) {
 			tt.mockSetup(mockService)
 
 			// Create controller with mock service
-			controller := NewURLController(mockService, slog.Default())
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
 
 			// Setup router
 			router := setupRouter(controller)
@@ -104,19 +194,38 @@ func TestSaveURL(t *testing.T) {
 
 func TestGetURL(t *testing.T) {
 	tests := []struct {
-		name           string
-		alias          string
-		expectedStatus int
-		expectedBody   string
-		mockSetup      func(*mocks.UrlService)
+		name             string
+		alias            string
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+		mockSetup        func(*mocks.UrlService)
 	}{
 		{
-			name:           "successful get",
+			name:             "successful get",
+			alias:            "test",
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com",
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{URL: "https://example.com", RedirectType: http.StatusFound}, nil)
+			},
+		},
+		{
+			name:             "permanent redirect",
+			alias:            "test",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://example.com",
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{URL: "https://example.com", RedirectType: http.StatusMovedPermanently}, nil)
+			},
+		},
+		{
+			name:           "visits limit reached",
 			alias:          "test",
-			expectedStatus: http.StatusOK,
-			expectedBody:   `{"originalURL":"https://example.com"}`,
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"error":"URL is no longer available"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("GetURL", "test").Return("https://example.com", nil)
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{}, services.ErrURLGone)
 			},
 		},
 		{
@@ -125,7 +234,7 @@ func TestGetURL(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   `{"error":"URL not found"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("GetURL", "notfound").Return("", services.ErrURLNotFound)
+				m.On("GetURL", mock.Anything, "", "notfound", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{}, services.ErrURLNotFound)
 			},
 		},
 		{
@@ -134,7 +243,7 @@ func TestGetURL(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"error":"internal server error"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("GetURL", "test").Return("", errors.New("internal server error"))
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{}, errors.New("internal server error"))
 			},
 		},
 	}
@@ -143,10 +252,11 @@ func TestGetURL(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup mock service
 			mockService := new(mocks.UrlService)
+			mockService.On("ResolveDomain", mock.Anything, "").Return("", nil).Maybe()
 			tt.mockSetup(mockService)
 
 			// Create controller with mock service
-			controller := NewURLController(mockService, slog.Default())
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
 
 			// Setup router
 			router := setupRouter(controller)
@@ -160,63 +270,943 @@ func TestGetURL(t *testing.T) {
 
 			// Assertions
 			assert.Equal(t, tt.expectedStatus, w.Code)
-			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			} else {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
 			mockService.AssertExpectations(t)
 		})
 	}
 }
 
-func TestDeleteURL(t *testing.T) {
+func TestGetURLCacheControl(t *testing.T) {
+	maxVisits := 5
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		link                 storage.URL
+		expectedCacheControl string
+	}{
+		{
+			name: "static permanent redirect",
+			link: storage.URL{URL: "https://example.com", RedirectType: http.StatusMovedPermanently},
+		},
+		{
+			name:                 "permanent redirect with visits limit",
+			link:                 storage.URL{URL: "https://example.com", RedirectType: http.StatusMovedPermanently, MaxVisits: &maxVisits},
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "permanent redirect with expiry",
+			link:                 storage.URL{URL: "https://example.com", RedirectType: http.StatusPermanentRedirect, ExpiresAt: &expiresAt},
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "permanent redirect with geo targets",
+			link:                 storage.URL{URL: "https://example.com", RedirectType: http.StatusMovedPermanently, GeoTargets: map[string]string{"DE": "https://example.de"}},
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "permanent redirect with rules",
+			link:                 storage.URL{URL: "https://example.com", RedirectType: http.StatusPermanentRedirect, Rules: []storage.RedirectRule{{URL: "https://example.com/b"}}},
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "permanent redirect with device rules",
+			link:                 storage.URL{URL: "https://example.com", RedirectType: http.StatusMovedPermanently, DeviceRules: []storage.DeviceRule{{OS: "ios", URL: "https://apps.apple.com"}}},
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "permanent redirect with variants",
+			link:                 storage.URL{URL: "https://example.com/a", RedirectType: http.StatusMovedPermanently, Variants: []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}}},
+			expectedCacheControl: "no-store",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			mockService.On("ResolveDomain", mock.Anything, "").Return("", nil)
+			mockService.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(tt.link, nil)
+
+			router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+			req, _ := http.NewRequest("GET", "/url/test", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.link.RedirectType, w.Code)
+			assert.Equal(t, tt.expectedCacheControl, w.Header().Get("Cache-Control"))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetURLVisitor(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("ResolveDomain", mock.Anything, "").Return("", nil)
+	mockService.On("GetURL", mock.Anything, "", "test", false, services.Visitor{IP: "81.2.69.142", UserAgent: "Mozilla/5.0 (iPhone)", AcceptLanguage: "de-AT,de;q=0.9",
+		Query: url.Values{"utm_source": {"mail"}}}).
+		Return(storage.URL{URL: "https://example.co.uk", RedirectType: http.StatusFound}, nil)
+
+	router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+	req, _ := http.NewRequest("GET", "/url/test?utm_source=mail", nil)
+	req.RemoteAddr = "81.2.69.142:40000"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone)")
+	req.Header.Set("Accept-Language", "de-AT,de;q=0.9")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.co.uk", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+func TestGetURLPrefixPath(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("ResolveDomain", mock.Anything, "").Return("", nil)
+	mockService.On("GetURL", mock.Anything, "", "docs/getting-started/install", false, services.Visitor{Query: url.Values{}}).
+		Return(storage.URL{URL: "https://docs.example.com/getting-started/install", RedirectType: http.StatusFound}, nil)
+
+	controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+	router := gin.Default()
+	router.GET("/url/:alias/*path", controller.GetURL)
+
+	req, _ := http.NewRequest("GET", "/url/docs/getting-started/install", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://docs.example.com/getting-started/install", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+func TestGetURLStickyVariant(t *testing.T) {
 	tests := []struct {
 		name           string
-		alias          string
+		cookie         string
+		link           storage.URL
+		expectedCookie string
+	}{
+		{
+			name:           "first visit",
+			link:           storage.URL{URL: "https://example.com/b", RedirectType: http.StatusFound, StickyVariants: true, Variant: "b"},
+			expectedCookie: "variant=b; Path=/url/test; Max-Age=2592000; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:           "returning visitor",
+			cookie:         "a",
+			link:           storage.URL{URL: "https://example.com/a", RedirectType: http.StatusFound, StickyVariants: true, Variant: "a"},
+			expectedCookie: "variant=a; Path=/url/test; Max-Age=2592000; HttpOnly; SameSite=Lax",
+		},
+		{
+			name: "not sticky",
+			link: storage.URL{URL: "https://example.com/a", RedirectType: http.StatusFound, Variant: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			mockService.On("ResolveDomain", mock.Anything, "").Return("", nil)
+			mockService.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}, Variant: tt.cookie}).Return(tt.link, nil)
+
+			router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+			req, _ := http.NewRequest("GET", "/url/test", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "variant", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tt.link.URL, w.Header().Get("Location"))
+			assert.Equal(t, tt.expectedCookie, w.Header().Get("Set-Cookie"))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetURLCustomDomain(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("ResolveDomain", mock.Anything, "go.brand.com").Return("go.brand.com", nil)
+	mockService.On("GetURL", mock.Anything, "go.brand.com", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{URL: "https://brand.com/landing", RedirectType: http.StatusFound}, nil)
+
+	router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+	req, _ := http.NewRequest("GET", "/url/test", nil)
+	req.Host = "go.brand.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://brand.com/landing", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+func TestPreview(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	link := storage.URL{Alias: "test", URL: "https://example.com", Visits: 4, CreatedAt: createdAt}
+	blocked := storage.URL{Alias: "test", URL: "https://example.com", CreatedAt: createdAt, BlockedAt: &createdAt, BlockReason: "phishing"}
+	maxVisits := 4
+	exhausted := storage.URL{Alias: "test", URL: "https://example.com", Visits: 4, MaxVisits: &maxVisits, CreatedAt: createdAt}
+	expired := storage.URL{Alias: "test", URL: "https://example.com", CreatedAt: createdAt, ExpiresAt: &createdAt}
+
+	tests := []struct {
+		name           string
+		path           string
+		accept         string
 		expectedStatus int
 		expectedBody   string
+		expectedHTML   string
 		mockSetup      func(*mocks.UrlService)
 	}{
 		{
-			name:           "successful delete",
-			alias:          "test",
+			name:           "plus suffix as json",
+			path:           "/url/test+",
+			accept:         "application/json",
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"status":"OK"}`,
+			expectedBody:   `{"alias":"test","url":"https://example.com","createdAt":"2025-01-02T03:04:05Z","visits":4,"warning":false,"continueURL":"/url/test?confirm=1"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("DeleteURL", "test").Return(nil)
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
 			},
 		},
 		{
-			name:           "delete error",
-			alias:          "test",
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"error during deletign the url"}`,
+			name:           "preview query as html",
+			path:           "/url/test?preview=1",
+			accept:         "text/html",
+			expectedStatus: http.StatusOK,
+			expectedHTML:   `<dd>https://example.com</dd>`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name:           "exhausted link",
+			path:           "/url/test+",
+			accept:         "application/json",
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"error":"URL is no longer available"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(exhausted, nil)
+			},
+		},
+		{
+			name:           "expired link",
+			path:           "/url/test?preview=1",
+			accept:         "text/html",
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"error":"URL is no longer available"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(expired, nil)
+			},
+		},
+		{
+			name:           "interstitial before redirect",
+			path:           "/url/test",
+			accept:         "application/json",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","url":"https://example.com","createdAt":"2025-01-02T03:04:05Z","visits":4,"warning":true,"continueURL":"/url/test?confirm=1"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{}, services.ErrURLNeedsPreview)
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name:           "interstitial keeps the query",
+			path:           "/url/test?utm_source=mail&preview=0",
+			accept:         "application/json",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","url":"https://example.com","createdAt":"2025-01-02T03:04:05Z","visits":4,"warning":true,"continueURL":"/url/test?confirm=1&utm_source=mail"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{"utm_source": {"mail"}}}).Return(storage.URL{}, services.ErrURLNeedsPreview)
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name:           "confirmed interstitial forwards the query",
+			path:           "/url/test?confirm=1&utm_source=mail",
+			expectedStatus: http.StatusFound,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", true, services.Visitor{Query: url.Values{"utm_source": {"mail"}}}).
+					Return(storage.URL{URL: "https://example.com?utm_source=mail", RedirectType: http.StatusFound}, nil)
+			},
+		},
+		{
+			name:           "confirmed interstitial redirects",
+			path:           "/url/test?confirm=1",
+			expectedStatus: http.StatusFound,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", true, services.Visitor{Query: url.Values{}}).Return(storage.URL{URL: "https://example.com", RedirectType: http.StatusFound}, nil)
+			},
+		},
+		{
+			name:           "blocked link as json",
+			path:           "/url/test",
+			accept:         "application/json",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"URL has been blocked","alias":"test","reason":"phishing"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{}, services.ErrURLBlocked)
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(blocked, nil)
+			},
+		},
+		{
+			name:           "blocked link as html",
+			path:           "/url/test",
+			accept:         "text/html",
+			expectedStatus: http.StatusForbidden,
+			expectedHTML:   `<p>Reason: phishing</p>`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURL", mock.Anything, "", "test", false, services.Visitor{Query: url.Values{}}).Return(storage.URL{}, services.ErrURLBlocked)
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(blocked, nil)
+			},
+		},
+		{
+			name:           "preview of a blocked link hides the destination",
+			path:           "/url/test+",
+			accept:         "application/json",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"URL has been blocked","alias":"test","reason":"phishing"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(blocked, nil)
+			},
+		},
+		{
+			name:           "preview of unknown alias",
+			path:           "/url/notfound+",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"URL not found"}`,
 			mockSetup: func(m *mocks.UrlService) {
-				m.On("DeleteURL", "test").Return(errors.New("error during deletign the url"))
+				m.On("GetURLInfo", mock.Anything, "", "notfound").Return(storage.URL{}, services.ErrURLNotFound)
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup mock service
 			mockService := new(mocks.UrlService)
+			mockService.On("ResolveDomain", mock.Anything, "").Return("", nil).Maybe()
 			tt.mockSetup(mockService)
 
-			// Create controller with mock service
-			controller := NewURLController(mockService, slog.Default())
-
-			// Setup router
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
 			router := setupRouter(controller)
 
-			// Create request
-			req, _ := http.NewRequest("DELETE", "/url/"+tt.alias, nil)
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
 
-			// Record response
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedHTML != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedHTML)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetQRCode(t *testing.T) {
+	tests := []struct {
+		name                string
+		query               string
+		expectedStatus      int
+		expectedContentType string
+		mockSetup           func(*mocks.UrlService)
+	}{
+		{
+			name:                "default png",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{Alias: "test"}, nil)
+			},
+		},
+		{
+			name:                "svg with options",
+			query:               "?format=svg&size=512&level=h&margin=2&fg=%23ff0000",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/svg+xml",
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{Alias: "test"}, nil)
+			},
+		},
+		{
+			name:           "invalid size",
+			query:          "?size=big",
+			expectedStatus: http.StatusBadRequest,
+			mockSetup:      func(m *mocks.UrlService) {},
+		},
+		{
+			name:           "url not found",
+			expectedStatus: http.StatusNotFound,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			mockService.On("ResolveDomain", mock.Anything, "").Return("", nil).Maybe()
+			tt.mockSetup(mockService)
+
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+			router := setupRouter(controller)
+
+			req, _ := http.NewRequest("GET", "/url/test/qr"+tt.query, nil)
 			w := httptest.NewRecorder()
 			router.ServeHTTP(w, req)
 
-			// Assertions
 			assert.Equal(t, tt.expectedStatus, w.Code)
-			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+			}
 			mockService.AssertExpectations(t)
 		})
 	}
 }
+
+func TestGetQRCodeNotModified(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("ResolveDomain", mock.Anything, "").Return("", nil)
+	mockService.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{Alias: "test"}, nil)
+
+	router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+	req, _ := http.NewRequest("GET", "/url/test/qr", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+
+	for _, ifNoneMatch := range []string{etag, `"stale", ` + etag, "W/" + etag, "*"} {
+		req, _ = http.NewRequest("GET", "/url/test/qr", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code, ifNoneMatch)
+		assert.Empty(t, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/url/test/qr", nil)
+	req.Header.Set("If-None-Match", `"stale", W/"older"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetQRCodeETagOfNormalizedOptions(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("ResolveDomain", mock.Anything, "").Return("", nil)
+	mockService.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{Alias: "test"}, nil)
+
+	router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+	etag := func(query string) string {
+		req, _ := http.NewRequest("GET", "/url/test/qr"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Header().Get("ETag")
+	}
+
+	assert.Equal(t, etag("?fg=ff0000"), etag("?fg=%23FF0000"))
+	assert.Equal(t, etag(""), etag("?bg=%23ffffff&level=m"))
+	assert.NotEqual(t, etag(""), etag("?fg=ff0000"))
+}
+
+func TestGetURLInfo(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	updatedAt := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+	lastVisitAt := time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		alias          string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "link with visits limit",
+			alias:          "test",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"admin","createdAt":"2025-01-02T03:04:05Z","updatedAt":"2025-01-03T00:00:00Z","expiresAt":null,"redirectType":302,"maxVisits":3,"visits":1,"remaining":2,"lastVisitAt":"2025-01-04T00:00:00Z","interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{Alias: "test", URL: "https://example.com", MaxVisits: intPtr(3), Visits: 1, CreatedAt: createdAt, RedirectType: 302,
+					Owner: "admin", UpdatedAt: &updatedAt, LastVisitAt: &lastVisitAt}, nil)
+			},
+		},
+		{
+			name:           "link without visits limit",
+			alias:          "test",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":307,"maxVisits":null,"visits":7,"remaining":null,"lastVisitAt":null,"interstitial":true,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "test").Return(storage.URL{Alias: "test", URL: "https://example.com", Visits: 7, CreatedAt: createdAt, Interstitial: true, RedirectType: 307}, nil)
+			},
+		},
+		{
+			name:           "url not found",
+			alias:          "notfound",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"URL not found"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("GetURLInfo", mock.Anything, "", "notfound").Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+			router := setupRouter(controller)
+
+			req, _ := http.NewRequest("GET", "/url/"+tt.alias+"/info", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateURL(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "successful update",
+			requestBody:    `{"urlToSave": "https://example.org", "maxVisits": 5}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.org","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":5,"visits":2,"remaining":3,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("UpdateURL", mock.Anything, storage.URL{URL: "https://example.org", Alias: "test", MaxVisits: intPtr(5)}, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.org", Alias: "test", MaxVisits: intPtr(5), Visits: 2, CreatedAt: createdAt, RedirectType: 302}, nil)
+			},
+		},
+		{
+			name:           "missing urlToSave",
+			requestBody:    `{"maxVisits": 5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"urlToSave is required"}`,
+			mockSetup:      func(m *mocks.UrlService) {},
+		},
+		{
+			name:           "invalid redirect type",
+			requestBody:    `{"urlToSave": "https://example.org", "redirectType": 200}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid input: redirectType must be one of 301, 302, 307, 308"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("UpdateURL", mock.Anything, storage.URL{URL: "https://example.org", Alias: "test", RedirectType: 200}, storage.Actor{}).
+					Return(storage.URL{}, fmt.Errorf("%w: redirectType must be one of 301, 302, 307, 308", services.ErrInvalidInput))
+			},
+		},
+		{
+			name:           "domain in the body and the query",
+			query:          "?domain=go.brand.com",
+			requestBody:    `{"urlToSave": "https://example.org", "domain": "go.brand.com"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"go.brand.com","shortURL":"https://go.brand.com/url/test","url":"https://example.org","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("UpdateURL", mock.Anything, storage.URL{URL: "https://example.org", Alias: "test", Domain: "go.brand.com"}, storage.Actor{}).
+					Return(storage.URL{URL: "https://example.org", Alias: "test", Domain: "go.brand.com", CreatedAt: createdAt, RedirectType: 302}, nil)
+			},
+		},
+		{
+			name:           "domain in the body only",
+			requestBody:    `{"urlToSave": "https://example.org", "domain": "go.brand.com"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"domain must match the domain query parameter"}`,
+			mockSetup:      func(m *mocks.UrlService) {},
+		},
+		{
+			name:           "url not found",
+			requestBody:    `{"urlToSave": "https://example.org"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"URL not found"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("UpdateURL", mock.Anything, storage.URL{URL: "https://example.org", Alias: "test"}, storage.Actor{}).Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+			router := setupRouter(controller)
+
+			req, _ := http.NewRequest("PUT", "/url/test"+tt.query, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListURLs(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "filtered by tag and folder",
+			query:          "?tag=promo&folder=marketing&limit=10&offset=20",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"links":[{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":["promo"],"folder":"marketing/2025","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}]}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Tag: "promo", Folder: "marketing", Limit: 10, Offset: 20}).
+					Return([]storage.URL{{Alias: "test", URL: "https://example.com", CreatedAt: createdAt, RedirectType: 302, Tags: []string{"promo"}, Folder: "marketing/2025"}}, nil)
+			},
+		},
+		{
+			name:           "empty listing",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"links":[]}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true}).Return([]storage.URL{}, nil)
+			},
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=many",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"limit must be a number"}`,
+			mockSetup:      func(m *mocks.UrlService) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+			router := setupRouter(controller)
+
+			req, _ := http.NewRequest("GET", "/url"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTagStats(t *testing.T) {
+	mockService := new(mocks.UrlService)
+	mockService.On("TagStats", mock.Anything).Return([]storage.TagStats{{Tag: "promo", Links: 2, Visits: 15}}, nil)
+
+	router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+	req, _ := http.NewRequest("GET", "/tags", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"tags":[{"tag":"promo","links":2,"visits":15}]}`, w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestGetLinkStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "split link",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","visits":5,"variants":[{"name":"a","url":"https://example.com/a","weight":1,"clicks":5},{"name":"b","url":"https://example.com/b","weight":2,"clicks":0}]}`,
+			mockSetup: func(m *mocks.UrlService) {
+				link := storage.URL{Alias: "test", URL: "https://example.com", Visits: 5, Variants: []storage.Variant{
+					{Name: "a", URL: "https://example.com/a", Weight: 1},
+					{Name: "b", URL: "https://example.com/b", Weight: 2},
+				}}
+				m.On("LinkStats", mock.Anything, "", "test").Return(storage.LinkStats{Link: link, VariantClicks: map[string]int{"a": 5}}, nil)
+			},
+		},
+		{
+			name:           "link without variants",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","visits":2,"variants":[]}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("LinkStats", mock.Anything, "", "test").Return(storage.LinkStats{Link: storage.URL{Alias: "test", Visits: 2}}, nil)
+			},
+		},
+		{
+			name:           "not found",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"URL not found"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("LinkStats", mock.Anything, "", "test").Return(storage.LinkStats{}, services.ErrURLNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+			req, _ := http.NewRequest("GET", "/url/test/stats", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDryRunRules(t *testing.T) {
+	at := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "matching rule",
+			requestBody:    `{"time": "2025-03-08T12:00:00Z", "acceptLanguage": "de-AT", "query": "ref=partner&utm_source=mail"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"matched":true,"rule":1,"url":"https://example.com/partner"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				visitor := services.Visitor{AcceptLanguage: "de-AT", Query: url.Values{"ref": {"partner"}, "utm_source": {"mail"}}}
+				m.On("MatchRule", mock.Anything, "", "test", visitor, at).
+					Return(services.RuleMatch{Matched: true, Index: 1, Rule: storage.RedirectRule{URL: "https://example.com/partner"}}, nil)
+			},
+		},
+		{
+			name:           "no matching rule",
+			requestBody:    `{"time": "2025-03-08T12:00:00Z"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"matched":false,"rule":null,"url":null}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("MatchRule", mock.Anything, "", "test", services.Visitor{Query: url.Values{}}, at).Return(services.RuleMatch{}, nil)
+			},
+		},
+		{
+			name:           "invalid query",
+			requestBody:    `{"query": "a=%zz"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"query must be a URL query string"}`,
+			mockSetup:      func(m *mocks.UrlService) {},
+		},
+		{
+			name:           "not found",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"URL not found"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("MatchRule", mock.Anything, "", "test", mock.Anything, mock.Anything).Return(services.RuleMatch{}, services.ErrURLNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			router := setupRouter(NewURLController(mockService, "https://sho.rt/url", slog.Default()))
+
+			req, _ := http.NewRequest("POST", "/url/test/rules/dry-run", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		alias          string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "successful delete",
+			alias:          "test",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("DeleteURL", mock.Anything, "", "test", storage.Actor{}).Return(nil)
+			},
+		},
+		{
+			name:           "delete error",
+			alias:          "test",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"error during deletign the url"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("DeleteURL", mock.Anything, "", "test", storage.Actor{}).Return(errors.New("error during deletign the url"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			// Create controller with mock service
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+
+			// Setup router
+			router := setupRouter(controller)
+
+			// Create request
+			req, _ := http.NewRequest("DELETE", "/url/"+tt.alias, nil)
+
+			// Record response
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBlockURL(t *testing.T) {
+	blockedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	blocked := storage.URL{Alias: "test", URL: "https://example.com", RedirectType: 302, CreatedAt: blockedAt, BlockedAt: &blockedAt, BlockReason: "phishing"}
+
+	tests := []struct {
+		name           string
+		method         string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.UrlService)
+	}{
+		{
+			name:           "block",
+			method:         "POST",
+			requestBody:    `{"reason": "phishing"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":"2025-01-02T03:04:05Z","blockReason":"phishing"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("BlockURL", mock.Anything, "", "test", "phishing", storage.Actor{}).Return(blocked, nil)
+			},
+		},
+		{
+			name:           "invalid reason",
+			method:         "POST",
+			requestBody:    `{"reason": "phishing"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid input: reason must be at most 500 characters"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("BlockURL", mock.Anything, "", "test", "phishing", storage.Actor{}).
+					Return(storage.URL{}, fmt.Errorf("%w: reason must be at most 500 characters", services.ErrInvalidInput))
+			},
+		},
+		{
+			name:           "invalid body",
+			method:         "POST",
+			requestBody:    `{"reason": 1}`,
+			expectedStatus: http.StatusBadRequest,
+			mockSetup:      func(*mocks.UrlService) {},
+		},
+		{
+			name:           "unblock",
+			method:         "DELETE",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"alias":"test","domain":"","shortURL":"https://sho.rt/url/test","url":"https://example.com","owner":"","createdAt":"2025-01-02T03:04:05Z","updatedAt":null,"expiresAt":null,"redirectType":302,"maxVisits":null,"visits":0,"remaining":null,"lastVisitAt":null,"interstitial":false,"tags":[],"folder":"","geoTargets":{},"rules":[],"deviceRules":[],"variants":[],"stickyVariants":false,"queryPassthrough":"","utm":{},"prefix":false,"blockedAt":null,"blockReason":""}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("UnblockURL", mock.Anything, "", "test", storage.Actor{}).
+					Return(storage.URL{Alias: "test", URL: "https://example.com", RedirectType: 302, CreatedAt: blockedAt}, nil)
+			},
+		},
+		{
+			name:           "unblock unknown alias",
+			method:         "DELETE",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"URL not found"}`,
+			mockSetup: func(m *mocks.UrlService) {
+				m.On("UnblockURL", mock.Anything, "", "test", storage.Actor{}).Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.UrlService)
+			tt.mockSetup(mockService)
+
+			controller := NewURLController(mockService, "https://sho.rt/url", slog.Default())
+			router := setupRouter(controller)
+
+			req, _ := http.NewRequest(tt.method, "/url/test/block", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
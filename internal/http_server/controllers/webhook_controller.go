@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+	"url_shortener/internal/services"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController interface {
+	SaveSubscription(ctx *gin.Context)
+	ListSubscriptions(ctx *gin.Context)
+	DeleteSubscription(ctx *gin.Context)
+	ListDeliveries(ctx *gin.Context)
+}
+
+type webhookController struct {
+	webhookService services.WebhookService
+	log            *slog.Logger
+}
+
+type WebhookRequest struct {
+	URL string `json:"url"`
+	// Secret signs the payloads, one is generated when it is empty.
+	Secret string `json:"secret"`
+	// Events the endpoint receives, empty for all of them.
+	Events []string `json:"events"`
+}
+
+type WebhookResponse struct {
+	ID     int64    `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret is only returned when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type DeliveryResponse struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int64      `json:"subscriptionId"`
+	EventID        int64      `json:"eventId"`
+	Event          string     `json:"event"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt"`
+	LastStatusCode *int       `json:"lastStatusCode"`
+	LastError      string     `json:"lastError"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+}
+
+type DeliveryListResponse struct {
+	Deliveries []DeliveryResponse `json:"deliveries"`
+}
+
+func NewWebhookController(webhookService services.WebhookService, logger *slog.Logger) *webhookController {
+	return &webhookController{webhookService: webhookService, log: logger}
+}
+
+func (c *webhookController) SaveSubscription(ctx *gin.Context) {
+	const fn = "controllers.webhook_controller.SaveSubscription"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	var requestJson WebhookRequest
+	if err := ctx.BindJSON(&requestJson); err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to parse json body", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := c.webhookService.SaveSubscription(ctx.Request.Context(), storage.Subscription{URL: requestJson.URL, Secret: requestJson.Secret, Events: requestJson.Events}, actor(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "server error during saving the subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := webhookResponse(sub)
+	response.Secret = sub.Secret
+	ctx.JSON(201, response)
+}
+
+func (c *webhookController) ListSubscriptions(ctx *gin.Context) {
+	const fn = "controllers.webhook_controller.ListSubscriptions"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	subs, err := c.webhookService.ListSubscriptions(ctx.Request.Context())
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "failed to list subscriptions", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := WebhookListResponse{Webhooks: make([]WebhookResponse, 0, len(subs))}
+	for _, sub := range subs {
+		response.Webhooks = append(response.Webhooks, webhookResponse(sub))
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (c *webhookController) DeleteSubscription(ctx *gin.Context) {
+	const fn = "controllers.webhook_controller.DeleteSubscription"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.ErrorContext(ctx.Request.Context(), "invalid subscription id", slog.String("id", ctx.Param("id")))
+		ctx.JSON(400, gin.H{"error": "id must be a number"})
+		return
+	}
+
+	if err := c.webhookService.DeleteSubscription(ctx.Request.Context(), id, actor(ctx)); err != nil {
+		if errors.Is(err, services.ErrSubscriptionNotFound) {
+			log.ErrorContext(ctx.Request.Context(), "subscription not found", slog.Int64("id", id))
+			ctx.JSON(404, gin.H{"error": "subscription not found"})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "error trying to delete the subscription", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"status": "OK",
+	})
+}
+
+func (c *webhookController) ListDeliveries(ctx *gin.Context) {
+	const fn = "controllers.webhook_controller.ListDeliveries"
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	filter := storage.DeliveryFilter{Status: ctx.Query("status")}
+
+	var err error
+	if subscription, ok := ctx.GetQuery("subscription"); ok {
+		if filter.SubscriptionID, err = strconv.ParseInt(subscription, 10, 64); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid subscription", slog.String("subscription", subscription))
+			ctx.JSON(400, gin.H{"error": "subscription must be a number"})
+			return
+		}
+	}
+	if limit, ok := ctx.GetQuery("limit"); ok {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid limit", slog.String("limit", limit))
+			ctx.JSON(400, gin.H{"error": "limit must be a number"})
+			return
+		}
+	}
+	if offset, ok := ctx.GetQuery("offset"); ok {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			log.ErrorContext(ctx.Request.Context(), "invalid offset", slog.String("offset", offset))
+			ctx.JSON(400, gin.H{"error": "offset must be a number"})
+			return
+		}
+	}
+
+	deliveries, err := c.webhookService.ListDeliveries(ctx.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.ErrorContext(ctx.Request.Context(), "invalid list parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		log.ErrorContext(ctx.Request.Context(), "failed to list deliveries", slog.String("error", err.Error()))
+		ctx.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	response := DeliveryListResponse{Deliveries: make([]DeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		response.Deliveries = append(response.Deliveries, deliveryResponse(d))
+	}
+
+	ctx.JSON(200, response)
+}
+
+func webhookResponse(sub storage.Subscription) WebhookResponse {
+	return WebhookResponse{ID: sub.ID, URL: sub.URL, Events: append([]string{}, sub.Events...), CreatedAt: sub.CreatedAt}
+}
+
+func deliveryResponse(d storage.Delivery) DeliveryResponse {
+	response := DeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.Event.ID,
+		Event:          d.Event.Type,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == storage.DeliveryPending {
+		response.NextAttemptAt = &d.NextAttemptAt
+	}
+	return response
+}
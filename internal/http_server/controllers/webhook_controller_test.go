@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url_shortener/internal/services"
+	"url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupWebhookRouter(controller WebhookController) *gin.Engine {
+	router := gin.Default()
+	router.GET("/webhooks", controller.ListSubscriptions)
+	router.POST("/webhooks", controller.SaveSubscription)
+	router.DELETE("/webhooks/:id", controller.DeleteSubscription)
+	router.GET("/webhooks/deliveries", controller.ListDeliveries)
+	return router
+}
+
+func TestWebhookController(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	statusCode := http.StatusServiceUnavailable
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+		mockSetup      func(*mocks.WebhookService)
+	}{
+		{
+			name:           "save subscription returns the secret",
+			method:         "POST",
+			path:           "/webhooks",
+			requestBody:    `{"url": "https://hooks.example.com/in", "events": ["link.created"]}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1,"url":"https://hooks.example.com/in","events":["link.created"],"secret":"s3cret","createdAt":"2025-01-02T03:04:05Z"}`,
+			mockSetup: func(m *mocks.WebhookService) {
+				m.On("SaveSubscription", mock.Anything, storage.Subscription{URL: "https://hooks.example.com/in", Events: []string{"link.created"}}, storage.Actor{}).
+					Return(storage.Subscription{ID: 1, URL: "https://hooks.example.com/in", Secret: "s3cret", Events: []string{"link.created"}, CreatedAt: createdAt}, nil)
+			},
+		},
+		{
+			name:           "save invalid subscription",
+			method:         "POST",
+			path:           "/webhooks",
+			requestBody:    `{"url": "/in"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid input: url must be an absolute http or https url"}`,
+			mockSetup: func(m *mocks.WebhookService) {
+				m.On("SaveSubscription", mock.Anything, storage.Subscription{URL: "/in"}, storage.Actor{}).
+					Return(storage.Subscription{}, fmt.Errorf("%w: url must be an absolute http or https url", services.ErrInvalidInput))
+			},
+		},
+		{
+			name:           "list subscriptions hides the secret",
+			method:         "GET",
+			path:           "/webhooks",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"webhooks":[{"id":1,"url":"https://hooks.example.com/in","events":[],"createdAt":"2025-01-02T03:04:05Z"}]}`,
+			mockSetup: func(m *mocks.WebhookService) {
+				m.On("ListSubscriptions", mock.Anything).Return([]storage.Subscription{{ID: 1, URL: "https://hooks.example.com/in", Secret: "s3cret", CreatedAt: createdAt}}, nil)
+			},
+		},
+		{
+			name:           "delete missing subscription",
+			method:         "DELETE",
+			path:           "/webhooks/3",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"subscription not found"}`,
+			mockSetup: func(m *mocks.WebhookService) {
+				m.On("DeleteSubscription", mock.Anything, int64(3), storage.Actor{}).Return(services.ErrSubscriptionNotFound)
+			},
+		},
+		{
+			name:           "delete with invalid id",
+			method:         "DELETE",
+			path:           "/webhooks/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"id must be a number"}`,
+			mockSetup:      func(m *mocks.WebhookService) {},
+		},
+		{
+			name:           "list failed deliveries",
+			method:         "GET",
+			path:           "/webhooks/deliveries?subscription=1&status=failed&limit=10",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deliveries":[{"id":7,"subscriptionId":1,"eventId":42,"event":"link.deleted","status":"failed","attempts":8,"nextAttemptAt":null,"lastStatusCode":503,"lastError":"unexpected status 503: ","createdAt":"2025-01-02T03:04:05Z","deliveredAt":null}]}`,
+			mockSetup: func(m *mocks.WebhookService) {
+				m.On("ListDeliveries", mock.Anything, storage.DeliveryFilter{SubscriptionID: 1, Status: "failed", Limit: 10}).Return([]storage.Delivery{{
+					ID:             7,
+					SubscriptionID: 1,
+					Event:          storage.Event{ID: 42, Type: storage.EventLinkDeleted},
+					Status:         storage.DeliveryFailed,
+					Attempts:       8,
+					NextAttemptAt:  createdAt,
+					LastStatusCode: &statusCode,
+					LastError:      "unexpected status 503: ",
+					CreatedAt:      createdAt,
+				}}, nil)
+			},
+		},
+		{
+			name:           "list deliveries with invalid limit",
+			method:         "GET",
+			path:           "/webhooks/deliveries?limit=ten",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"limit must be a number"}`,
+			mockSetup:      func(m *mocks.WebhookService) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.WebhookService)
+			tt.mockSetup(mockService)
+
+			router := setupWebhookRouter(NewWebhookController(mockService, slog.Default()))
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
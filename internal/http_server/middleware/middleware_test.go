@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url_shortener/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestID      string
+		path           string
+		expectedStatus int
+		expectedLevel  string
+		keepsID        bool
+	}{
+		{
+			name:           "client request id is kept",
+			requestID:      "abc-123",
+			path:           "/url/test",
+			expectedStatus: http.StatusOK,
+			expectedLevel:  "INFO",
+			keepsID:        true,
+		},
+		{
+			name:           "missing request id is generated",
+			path:           "/url/test",
+			expectedStatus: http.StatusOK,
+			expectedLevel:  "INFO",
+		},
+		{
+			name:           "invalid request id is replaced",
+			requestID:      "two words",
+			path:           "/missing",
+			expectedStatus: http.StatusNotFound,
+			expectedLevel:  "WARN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := slog.New(logger.NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+			var handlerID string
+			r := gin.New()
+			r.Use(RequestID(), AccessLog(log))
+			r.GET("/url/:alias", func(ctx *gin.Context) {
+				handlerID = logger.RequestID(ctx.Request.Context())
+				ctx.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.requestID != "" {
+				req.Header.Set(logger.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(logger.RequestIDHeader)
+			if tt.keepsID {
+				assert.Equal(t, tt.requestID, id)
+			} else {
+				assert.True(t, logger.ValidRequestID(id))
+				assert.NotEqual(t, tt.requestID, id)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, id, handlerID)
+			}
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, tt.expectedLevel, record["level"])
+			assert.Equal(t, id, record["request_id"])
+			assert.Equal(t, "GET", record["method"])
+			assert.Equal(t, tt.path, record["path"])
+			assert.Equal(t, float64(tt.expectedStatus), record["status"])
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	var handlerSpan trace.SpanContext
+	r := gin.New()
+	r.Use(Tracing())
+	r.GET("/url/:alias", func(ctx *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(ctx.Request.Context())
+		ctx.String(http.StatusOK, "ok")
+	})
+	r.GET("/fail", func(ctx *gin.Context) {
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/url/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	redirect := spans[0]
+	assert.Equal(t, "GET /url/:alias", redirect.Name())
+	assert.Equal(t, trace.SpanKindServer, redirect.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", redirect.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", redirect.Parent().SpanID().String())
+	assert.True(t, redirect.Parent().IsRemote())
+	assert.Equal(t, redirect.SpanContext().SpanID(), handlerSpan.SpanID())
+	assert.Equal(t, codes.Unset, redirect.Status().Code)
+
+	failed := spans[1]
+	assert.Equal(t, "GET /fail", failed.Name())
+	assert.False(t, failed.Parent().IsValid())
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Contains(t, failed.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"url_shortener/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID keeps the X-Request-ID sent by the client, or generates one, puts
+// it in the request context and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(logger.RequestIDHeader)
+		if !logger.ValidRequestID(id) {
+			id = logger.NewRequestID()
+		}
+
+		ctx.Request = ctx.Request.WithContext(logger.WithRequestID(ctx.Request.Context(), id))
+		ctx.Header(logger.RequestIDHeader, id)
+		ctx.Next()
+	}
+}
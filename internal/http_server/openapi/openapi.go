@@ -0,0 +1,19 @@
+// Package openapi holds the OpenAPI 3 document of the HTTP API.
+package openapi
+
+import (
+	_ "embed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec is the OpenAPI document. It is maintained by hand, so every change to
+// the routes, request or response types has to be reflected in openapi.json.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Handler serves the OpenAPI document.
+func Handler(ctx *gin.Context) {
+	ctx.Data(200, "application/json; charset=utf-8", Spec)
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"url_shortener/internal/config"
+	"url_shortener/internal/http_server/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAuditRoutes(r *gin.Engine, auditController controllers.AuditController, cfg config.Config) {
+	auth := gin.BasicAuth(gin.Accounts{
+		cfg.HttpServer.User: cfg.HttpServer.Password,
+	})
+
+	for _, prefix := range apiPaths(cfg) {
+		auditGroup := r.Group(prefix+"/audit", auth)
+		{
+			auditGroup.GET("", auditController.ListAudit)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"url_shortener/internal/http_server/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDocsRoutes(r *gin.Engine) {
+	r.GET(APIVersionPath+"/openapi.json", openapi.Handler)
+}
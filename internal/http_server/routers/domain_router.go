@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"url_shortener/internal/config"
+	"url_shortener/internal/http_server/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDomainRoutes(r *gin.Engine, domainController controllers.DomainController, cfg config.Config) {
+	auth := gin.BasicAuth(gin.Accounts{
+		cfg.HttpServer.User: cfg.HttpServer.Password,
+	})
+
+	for _, prefix := range apiPaths(cfg) {
+		domainGroup := r.Group(prefix+"/domains", auth)
+		{
+			domainGroup.GET("", domainController.ListDomains)
+			domainGroup.POST("", domainController.SaveDomain)
+			domainGroup.DELETE("/:name", domainController.DeleteDomain)
+		}
+	}
+}
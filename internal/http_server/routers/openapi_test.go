@@ -0,0 +1,461 @@
+package routers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/http_server/controllers"
+	"url_shortener/internal/http_server/openapi"
+	"url_shortener/internal/services"
+	"url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("image/png", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("image/svg+xml", openapi3filter.PlainBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.PlainBodyDecoder)
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	return doc
+}
+
+func setupAPI(urlService *mocks.UrlService, domainService *mocks.DomainService, webhookService *mocks.WebhookService, auditService *mocks.AuditService) *gin.Engine {
+	cfg := config.Config{HttpServer: config.HttpServer{User: "user", Password: "secret"}}
+
+	r := gin.New()
+	SetupURLRoutes(r, controllers.NewURLController(urlService, "https://sho.rt/url", slog.Default()), cfg)
+	SetupDomainRoutes(r, controllers.NewDomainController(domainService, slog.Default()), cfg)
+	SetupWebhookRoutes(r, controllers.NewWebhookController(webhookService, slog.Default()), cfg)
+	SetupAuditRoutes(r, controllers.NewAuditController(auditService, slog.Default()), cfg)
+	SetupDocsRoutes(r)
+	return r
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	doc := loadSpec(t)
+	r := setupAPI(new(mocks.UrlService), new(mocks.DomainService), new(mocks.WebhookService), new(mocks.AuditService))
+
+	for _, route := range r.Routes() {
+		path := route.Path
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				path = strings.Replace(path, segment, "{"+segment[1:]+"}", 1)
+			}
+		}
+
+		// the unversioned management routes mirror the /api/v1 ones
+		operation := findOperation(doc, path, route.Method)
+		if operation == nil && !strings.HasPrefix(path, APIVersionPath) {
+			operation = findOperation(doc, APIVersionPath+path, route.Method)
+		}
+		assert.NotNil(t, operation, "%s %s is not documented", route.Method, route.Path)
+	}
+}
+
+func findOperation(doc *openapi3.T, path string, method string) *openapi3.Operation {
+	item := doc.Paths.Value(path)
+	if item == nil {
+		return nil
+	}
+	return item.GetOperation(method)
+}
+
+func TestResponsesMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	maxVisits := 3
+	link := storage.URL{Alias: "test", URL: "https://example.com", MaxVisits: &maxVisits, Visits: 1, CreatedAt: createdAt,
+		RedirectType: http.StatusFound, Owner: "user", UpdatedAt: &createdAt, Tags: []string{"promo"}, Folder: "marketing"}
+	domain := storage.Domain{Name: "go.brand.com", CreatedAt: createdAt}
+	subscription := storage.Subscription{ID: 1, URL: "https://hooks.example.com/in", Secret: "s3cret", Events: []string{storage.EventLinkCreated}, CreatedAt: createdAt}
+	statusCode := http.StatusServiceUnavailable
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		accept         string
+		noAuth         bool
+		expectedStatus int
+		mockSetup      func(*mocks.UrlService, *mocks.DomainService)
+		webhookSetup   func(*mocks.WebhookService)
+		auditSetup     func(*mocks.AuditService)
+	}{
+		{
+			name: "create link", method: "POST", path: "/api/v1/url/", body: `{"urlToSave": "https://example.com", "alias": "test", "tags": ["promo"]}`,
+			expectedStatus: http.StatusCreated,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("SaveURL", mock.Anything, mock.Anything, false, storage.Actor{User: "user", IP: "192.0.2.1"}).Return(link, nil)
+			},
+		},
+		{
+			name: "reuse existing link", method: "POST", path: "/api/v1/url/", body: `{"urlToSave": "https://example.com", "reuseExisting": true}`,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				reused := link
+				reused.Reused = true
+				u.On("SaveURL", mock.Anything, mock.Anything, true, mock.Anything).Return(reused, nil)
+			},
+		},
+		{
+			name: "create link without destination", method: "POST", path: "/api/v1/url/", body: `{"alias": "test"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "create existing alias", method: "POST", path: "/api/v1/url/", body: `{"urlToSave": "https://example.com", "alias": "test"}`,
+			expectedStatus: http.StatusConflict,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("SaveURL", mock.Anything, mock.Anything, false, mock.Anything).Return(storage.URL{}, services.ErrURLAlreadyExists)
+			},
+		},
+		{
+			name: "create link while the reputation lists are unavailable", method: "POST", path: "/api/v1/url/", body: `{"urlToSave": "https://example.com"}`,
+			expectedStatus: http.StatusServiceUnavailable,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("SaveURL", mock.Anything, mock.Anything, false, mock.Anything).Return(storage.URL{}, services.ErrReputationUnavailable)
+			},
+		},
+		{
+			name: "create link without credentials", method: "POST", path: "/api/v1/url/", body: `{"urlToSave": "https://example.com"}`,
+			noAuth: true, expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "list links", method: "GET", path: "/api/v1/url/?tag=promo&limit=10",
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("ListURLs", mock.Anything, mock.Anything).Return([]storage.URL{link, {Alias: "plain", URL: "https://example.org", CreatedAt: createdAt, RedirectType: 301}}, nil)
+			},
+		},
+		{
+			name: "list links with invalid pagination", method: "GET", path: "/api/v1/url/?limit=5000",
+			expectedStatus: http.StatusBadRequest,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("ListURLs", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidInput)
+			},
+		},
+		{
+			name: "link info", method: "GET", path: "/api/v1/url/test/info",
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name: "link info not found", method: "GET", path: "/api/v1/url/missing/info",
+			expectedStatus: http.StatusNotFound,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURLInfo", mock.Anything, "", "missing").Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+		{
+			name: "link stats", method: "GET", path: "/api/v1/url/test/stats",
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				split := link
+				split.Variants = []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 1}}
+				u.On("LinkStats", mock.Anything, "", "test").Return(storage.LinkStats{Link: split, VariantClicks: map[string]int{"a": 3}}, nil)
+			},
+		},
+		{
+			name: "dry run rules", method: "POST", path: "/api/v1/url/test/rules/dry-run", body: `{"acceptLanguage": "de", "query": "utm_source=mail"}`,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("MatchRule", mock.Anything, "", "test", mock.Anything, mock.Anything).
+					Return(services.RuleMatch{Matched: true, Index: 1, Rule: storage.RedirectRule{Languages: []string{"de"}, URL: "https://example.de"}}, nil)
+			},
+		},
+		{
+			name: "dry run rules without match", method: "POST", path: "/api/v1/url/test/rules/dry-run", body: `{"time": "2025-01-01T12:00:00Z"}`,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("MatchRule", mock.Anything, "", "test", mock.Anything, mock.Anything).Return(services.RuleMatch{}, nil)
+			},
+		},
+		{
+			name: "update link", method: "PUT", path: "/api/v1/url/test", body: `{"urlToSave": "https://example.org", "maxVisits": 3}`,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("UpdateURL", mock.Anything, mock.Anything, mock.Anything).Return(link, nil)
+			},
+		},
+		{
+			name: "update missing link", method: "PUT", path: "/api/v1/url/missing", body: `{"urlToSave": "https://example.org"}`,
+			expectedStatus: http.StatusNotFound,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("UpdateURL", mock.Anything, mock.Anything, mock.Anything).Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+		{
+			name: "delete link", method: "DELETE", path: "/api/v1/url/test",
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("DeleteURL", mock.Anything, "", "test", mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "block link", method: "POST", path: "/api/v1/url/test/block", body: `{"reason": "phishing"}`,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				blocked := link
+				blocked.BlockedAt, blocked.BlockReason = &createdAt, "phishing"
+				u.On("BlockURL", mock.Anything, "", "test", "phishing", mock.Anything).Return(blocked, nil)
+			},
+		},
+		{
+			name: "unblock missing link", method: "DELETE", path: "/api/v1/url/missing/block",
+			expectedStatus: http.StatusNotFound,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("UnblockURL", mock.Anything, "", "missing", mock.Anything).Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+		{
+			name: "tag stats", method: "GET", path: "/api/v1/tags",
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("TagStats", mock.Anything).Return([]storage.TagStats{{Tag: "promo", Links: 1, Visits: 1}}, nil)
+			},
+		},
+		{
+			name: "redirect", method: "GET", path: "/url/test", noAuth: true,
+			expectedStatus: http.StatusFound,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURL", mock.Anything, "", "test", false, mock.Anything).Return(link, nil)
+			},
+		},
+		{
+			name: "redirect to exhausted link", method: "GET", path: "/url/test", noAuth: true,
+			expectedStatus: http.StatusGone,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURL", mock.Anything, "", "test", false, mock.Anything).Return(storage.URL{}, services.ErrURLGone)
+			},
+		},
+		{
+			name: "redirect to missing link", method: "GET", path: "/url/missing", noAuth: true,
+			expectedStatus: http.StatusNotFound,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURL", mock.Anything, "", "missing", false, mock.Anything).Return(storage.URL{}, services.ErrURLNotFound)
+			},
+		},
+		{
+			name: "redirect to blocked link", method: "GET", path: "/url/test", accept: "application/json", noAuth: true,
+			expectedStatus: http.StatusForbidden,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				blocked := link
+				blocked.BlockedAt, blocked.BlockReason = &createdAt, "phishing"
+				u.On("GetURL", mock.Anything, "", "test", false, mock.Anything).Return(storage.URL{}, services.ErrURLBlocked)
+				u.On("GetURLInfo", mock.Anything, "", "test").Return(blocked, nil)
+			},
+		},
+		{
+			name: "preview as json", method: "GET", path: "/url/test?preview=1", accept: "application/json", noAuth: true,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name: "interstitial as html", method: "GET", path: "/url/test", accept: "text/html", noAuth: true,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURL", mock.Anything, "", "test", false, mock.Anything).Return(storage.URL{}, services.ErrURLNeedsPreview)
+				u.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name: "qr code png", method: "GET", path: "/url/test/qr", noAuth: true,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name: "qr code svg", method: "GET", path: "/url/test/qr?format=svg&size=128", noAuth: true,
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				u.On("GetURLInfo", mock.Anything, "", "test").Return(link, nil)
+			},
+		},
+		{
+			name: "qr code with invalid options", method: "GET", path: "/url/test/qr?size=1", noAuth: true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "list domains", method: "GET", path: "/api/v1/domains",
+			expectedStatus: http.StatusOK,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				d.On("ListDomains", mock.Anything).Return([]storage.Domain{domain}, nil)
+			},
+		},
+		{
+			name: "create domain", method: "POST", path: "/api/v1/domains", body: `{"name": "go.brand.com"}`,
+			expectedStatus: http.StatusCreated,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				d.On("SaveDomain", mock.Anything, "go.brand.com", mock.Anything).Return(domain, nil)
+			},
+		},
+		{
+			name: "create existing domain", method: "POST", path: "/api/v1/domains", body: `{"name": "go.brand.com"}`,
+			expectedStatus: http.StatusConflict,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				d.On("SaveDomain", mock.Anything, "go.brand.com", mock.Anything).Return(storage.Domain{}, services.ErrDomainAlreadyExists)
+			},
+		},
+		{
+			name: "delete domain in use", method: "DELETE", path: "/api/v1/domains/go.brand.com",
+			expectedStatus: http.StatusConflict,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				d.On("DeleteDomain", mock.Anything, "go.brand.com", mock.Anything).Return(services.ErrDomainInUse)
+			},
+		},
+		{
+			name: "delete missing domain", method: "DELETE", path: "/api/v1/domains/go.brand.com",
+			expectedStatus: http.StatusNotFound,
+			mockSetup: func(u *mocks.UrlService, d *mocks.DomainService) {
+				d.On("DeleteDomain", mock.Anything, "go.brand.com", mock.Anything).Return(services.ErrDomainNotFound)
+			},
+		},
+		{
+			name: "create webhook", method: "POST", path: "/api/v1/webhooks", body: `{"url": "https://hooks.example.com/in", "events": ["link.created"]}`,
+			expectedStatus: http.StatusCreated,
+			webhookSetup: func(w *mocks.WebhookService) {
+				w.On("SaveSubscription", mock.Anything, mock.Anything, mock.Anything).Return(subscription, nil)
+			},
+		},
+		{
+			name: "create webhook with unknown event", method: "POST", path: "/api/v1/webhooks", body: `{"url": "https://hooks.example.com/in", "events": ["link.visited"]}`,
+			expectedStatus: http.StatusBadRequest,
+			webhookSetup: func(w *mocks.WebhookService) {
+				w.On("SaveSubscription", mock.Anything, mock.Anything, mock.Anything).Return(storage.Subscription{}, services.ErrInvalidInput)
+			},
+		},
+		{
+			name: "list webhooks", method: "GET", path: "/api/v1/webhooks",
+			expectedStatus: http.StatusOK,
+			webhookSetup: func(w *mocks.WebhookService) {
+				w.On("ListSubscriptions", mock.Anything).Return([]storage.Subscription{subscription}, nil)
+			},
+		},
+		{
+			name: "delete missing webhook", method: "DELETE", path: "/api/v1/webhooks/1",
+			expectedStatus: http.StatusNotFound,
+			webhookSetup: func(w *mocks.WebhookService) {
+				w.On("DeleteSubscription", mock.Anything, int64(1), mock.Anything).Return(services.ErrSubscriptionNotFound)
+			},
+		},
+		{
+			name: "list deliveries", method: "GET", path: "/api/v1/webhooks/deliveries?status=pending",
+			expectedStatus: http.StatusOK,
+			webhookSetup: func(w *mocks.WebhookService) {
+				w.On("ListDeliveries", mock.Anything, mock.Anything).Return([]storage.Delivery{
+					{ID: 7, SubscriptionID: 1, Event: storage.Event{ID: 42, Type: storage.EventLinkCreated}, Status: storage.DeliveryPending,
+						Attempts: 1, NextAttemptAt: createdAt, LastStatusCode: &statusCode, LastError: "unexpected status 503: ", CreatedAt: createdAt},
+					{ID: 8, SubscriptionID: 1, Event: storage.Event{ID: 43, Type: storage.EventLinkThreshold}, Status: storage.DeliveryDelivered,
+						Attempts: 1, CreatedAt: createdAt, DeliveredAt: &createdAt},
+				}, nil)
+			},
+		},
+		{
+			name: "audit log", method: "GET", path: "/api/v1/audit?action=link.update&since=2025-01-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+			auditSetup: func(a *mocks.AuditService) {
+				a.On("ListAudit", mock.Anything, mock.Anything).Return([]storage.AuditEntry{
+					{ID: 2, Action: storage.AuditLinkUpdate, Actor: storage.Actor{User: "user", IP: "192.0.2.1"}, Target: "test",
+						Before: json.RawMessage(`{"url":"https://example.com"}`), After: json.RawMessage(`{"url":"https://example.org"}`), CreatedAt: createdAt},
+					{ID: 1, Action: storage.AuditWebhookDelete, Actor: storage.Actor{User: "user"}, Target: "1",
+						Before: json.RawMessage(`{"id":1,"url":"https://hooks.example.com/in"}`), CreatedAt: createdAt},
+				}, nil)
+			},
+		},
+		{
+			name: "audit log with invalid time", method: "GET", path: "/api/v1/audit?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "openapi document", method: "GET", path: "/api/v1/openapi.json", noAuth: true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urlService := new(mocks.UrlService)
+			domainService := new(mocks.DomainService)
+			webhookService := new(mocks.WebhookService)
+			auditService := new(mocks.AuditService)
+			urlService.On("ResolveDomain", mock.Anything, mock.Anything).Return("", nil).Maybe()
+			if tt.mockSetup != nil {
+				tt.mockSetup(urlService, domainService)
+			}
+			if tt.webhookSetup != nil {
+				tt.webhookSetup(webhookService)
+			}
+			if tt.auditSetup != nil {
+				tt.auditSetup(auditService)
+			}
+
+			newRequest := func() *http.Request {
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				if tt.body != "" {
+					req.Header.Set("Content-Type", "application/json")
+				}
+				if tt.accept != "" {
+					req.Header.Set("Accept", tt.accept)
+				}
+				if !tt.noAuth {
+					req.SetBasicAuth("user", "secret")
+				}
+				return req
+			}
+
+			w := httptest.NewRecorder()
+			setupAPI(urlService, domainService, webhookService, auditService).ServeHTTP(w, newRequest())
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			req := newRequest()
+			route, pathParams, err := router.FindRoute(req)
+			require.NoError(t, err)
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status: w.Code,
+				Header: w.Header(),
+				Body:   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+			})
+			assert.NoError(t, err)
+			urlService.AssertExpectations(t)
+			domainService.AssertExpectations(t)
+			webhookService.AssertExpectations(t)
+			auditService.AssertExpectations(t)
+		})
+	}
+}
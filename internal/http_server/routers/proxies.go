@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"url_shortener/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTrustedProxies makes the client IP of a request come from the
+// X-Forwarded-For and X-Real-IP headers only when the request was sent by one
+// of the configured proxies. Without proxies the address of the peer is used,
+// as gin otherwise trusts the headers of every client.
+func SetupTrustedProxies(r *gin.Engine, cfg config.Config) error {
+	if len(cfg.TrustedProxies) == 0 {
+		return r.SetTrustedProxies(nil)
+	}
+	return r.SetTrustedProxies(cfg.TrustedProxies)
+}
@@ -0,0 +1,59 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url_shortener/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		expectedIP     string
+	}{
+		{
+			name:       "spoofed header from untrusted peer is ignored",
+			expectedIP: "203.0.113.9",
+		},
+		{
+			name:           "peer outside of the trusted proxies",
+			trustedProxies: []string{"10.0.0.0/8"},
+			expectedIP:     "203.0.113.9",
+		},
+		{
+			name:           "header from trusted proxy",
+			trustedProxies: []string{"203.0.113.0/24"},
+			expectedIP:     "198.51.100.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			cfg := config.Config{HttpServer: config.HttpServer{TrustedProxies: tt.trustedProxies}}
+			require.NoError(t, SetupTrustedProxies(r, cfg))
+			r.GET("/ip", func(ctx *gin.Context) { ctx.String(http.StatusOK, ctx.ClientIP()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "203.0.113.9:41000"
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedIP, w.Body.String())
+		})
+	}
+}
+
+func TestSetupTrustedProxiesInvalid(t *testing.T) {
+	cfg := config.Config{HttpServer: config.HttpServer{TrustedProxies: []string{"not-an-ip"}}}
+	assert.Error(t, SetupTrustedProxies(gin.New(), cfg))
+}
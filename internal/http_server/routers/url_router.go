@@ -7,15 +7,75 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupURLRoutes(r *gin.Engine, urlController controllers.UrlContoller, cfg config.Config) {
-	urlGroup := r.Group("/url")
+// APIVersionPath is the prefix of the versioned management API.
+const APIVersionPath = "/api/v1"
+
+// RedirectPath is the path prefix short links are served under.
+func RedirectPath(cfg config.Config) string {
+	if cfg.RootRedirects {
+		return ""
+	}
+	return "/url"
+}
+
+// apiPaths lists the prefixes the management API is served under. The
+// unversioned routes are kept for existing clients unless short links are
+// served at the root path.
+func apiPaths(cfg config.Config) []string {
+	if cfg.RootRedirects {
+		return []string{APIVersionPath}
+	}
+	return []string{APIVersionPath, ""}
+}
 
-	urlGroup.GET("/:alias", urlController.GetURL)
-	secured := urlGroup.Group("/", gin.BasicAuth(gin.Accounts{
+func SetupURLRoutes(r *gin.Engine, urlController controllers.UrlContoller, cfg config.Config) {
+	auth := gin.BasicAuth(gin.Accounts{
 		cfg.HttpServer.User: cfg.HttpServer.Password,
-	}))
-	{
-		secured.POST("/", urlController.SaveURL)
-		secured.DELETE("/:alias", urlController.DeleteURL)
+	})
+
+	// sub-paths the catch-all route of prefix links leaves to other handlers,
+	// prefix links never forward them
+	subRoutes := map[string][]gin.HandlerFunc{"/qr": {urlController.GetQRCode}}
+
+	redirectGroup := r.Group(RedirectPath(cfg))
+	redirectGroup.GET("/:alias", urlController.GetURL)
+	redirectGroup.GET("/:alias/*path", func(ctx *gin.Context) {
+		handlers, ok := subRoutes[ctx.Param("path")]
+		if !ok {
+			urlController.GetURL(ctx)
+			return
+		}
+		for _, handler := range handlers {
+			if handler(ctx); ctx.IsAborted() {
+				return
+			}
+		}
+	})
+
+	for _, prefix := range apiPaths(cfg) {
+		secured := r.Group(prefix+"/url/", auth)
+		{
+			secured.GET("/", urlController.ListURLs)
+			secured.POST("/", urlController.SaveURL)
+			// gin cannot register them next to the catch-all route of the
+			// redirects sharing their path
+			if prefix+"/url" == RedirectPath(cfg) {
+				subRoutes["/info"] = []gin.HandlerFunc{auth, urlController.GetURLInfo}
+				subRoutes["/stats"] = []gin.HandlerFunc{auth, urlController.GetLinkStats}
+			} else {
+				secured.GET("/:alias/info", urlController.GetURLInfo)
+				secured.GET("/:alias/stats", urlController.GetLinkStats)
+			}
+			secured.POST("/:alias/rules/dry-run", urlController.DryRunRules)
+			secured.POST("/:alias/block", urlController.BlockURL)
+			secured.DELETE("/:alias/block", urlController.UnblockURL)
+			secured.PUT("/:alias", urlController.UpdateURL)
+			secured.DELETE("/:alias", urlController.DeleteURL)
+		}
+
+		tagGroup := r.Group(prefix+"/tags", auth)
+		{
+			tagGroup.GET("", urlController.GetTagStats)
+		}
 	}
 }
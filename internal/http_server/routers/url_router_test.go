@@ -0,0 +1,148 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/http_server/controllers/mocks"
+	servicemocks "url_shortener/internal/services/mocks"
+	"url_shortener/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupURLRoutes(t *testing.T) {
+	tests := []struct {
+		name          string
+		rootRedirects bool
+		redirectPath  string
+		infoPath      string
+	}{
+		{
+			name:         "default mode",
+			redirectPath: "/url/test",
+			infoPath:     "/url/test/info",
+		},
+		{
+			name:         "versioned api in default mode",
+			redirectPath: "/url/test",
+			infoPath:     "/api/v1/url/test/info",
+		},
+		{
+			name:          "root redirects",
+			rootRedirects: true,
+			redirectPath:  "/test",
+			infoPath:      "/api/v1/url/test/info",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{HttpServer: config.HttpServer{User: "user", Password: "secret", RootRedirects: tt.rootRedirects}}
+
+			controller := mocks.NewUrlContoller(t)
+			controller.On("GetURL", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).Status(http.StatusFound)
+			})
+			controller.On("GetURLInfo", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).Status(http.StatusOK)
+			})
+
+			r := gin.New()
+			SetupURLRoutes(r, controller, cfg)
+
+			req, _ := http.NewRequest("GET", tt.redirectPath, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusFound, w.Code)
+
+			req, _ = http.NewRequest("GET", tt.infoPath, nil)
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+			req, _ = http.NewRequest("GET", tt.infoPath, nil)
+			req.SetBasicAuth("user", "secret")
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestSetupURLRoutesPrefixPaths(t *testing.T) {
+	for _, rootRedirects := range []bool{false, true} {
+		cfg := config.Config{HttpServer: config.HttpServer{User: "user", Password: "secret", RootRedirects: rootRedirects}}
+		redirectPath := RedirectPath(cfg)
+
+		controller := mocks.NewUrlContoller(t)
+		controller.On("GetURL", mock.Anything).Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(*gin.Context)
+			assert.Equal(t, "docs", ctx.Param("alias"))
+			assert.Equal(t, "/getting-started/install", ctx.Param("path"))
+			ctx.Status(http.StatusFound)
+		})
+		controller.On("GetQRCode", mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(0).(*gin.Context).Status(http.StatusOK)
+		})
+
+		r := gin.New()
+		SetupURLRoutes(r, controller, cfg)
+
+		req, _ := http.NewRequest("GET", redirectPath+"/docs/getting-started/install", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+
+		req, _ = http.NewRequest("GET", redirectPath+"/docs/qr", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestSaveURLOwner(t *testing.T) {
+	// the service keeps the saved links like the storage would
+	saved := map[string]storage.URL{}
+	urlService := new(servicemocks.UrlService)
+	urlService.On("SaveURL", mock.Anything, mock.Anything, false, mock.Anything).Return(func(_ context.Context, link storage.URL, _ bool, _ storage.Actor) (storage.URL, error) {
+		link.RedirectType = http.StatusFound
+		saved[link.Alias] = link
+		return link, nil
+	})
+	urlService.On("GetURLInfo", mock.Anything, "", "test").Return(func(_ context.Context, _ string, alias string) (storage.URL, error) {
+		return saved[alias], nil
+	})
+
+	r := setupAPI(urlService, new(servicemocks.DomainService), new(servicemocks.WebhookService), new(servicemocks.AuditService))
+
+	req, _ := http.NewRequest("POST", "/api/v1/url/", strings.NewReader(`{"urlToSave": "https://example.com", "alias": "test"}`))
+	req.SetBasicAuth("user", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct{ Owner string }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "user", created.Owner)
+	assert.Equal(t, "user", saved["test"].Owner)
+
+	req, _ = http.NewRequest("GET", "/api/v1/url/test/info", nil)
+	req.SetBasicAuth("user", "secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var info struct{ Owner string }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "user", info.Owner)
+	urlService.AssertExpectations(t)
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"url_shortener/internal/config"
+	"url_shortener/internal/http_server/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupWebhookRoutes(r *gin.Engine, webhookController controllers.WebhookController, cfg config.Config) {
+	auth := gin.BasicAuth(gin.Accounts{
+		cfg.HttpServer.User: cfg.HttpServer.Password,
+	})
+
+	for _, prefix := range apiPaths(cfg) {
+		webhookGroup := r.Group(prefix+"/webhooks", auth)
+		{
+			webhookGroup.GET("", webhookController.ListSubscriptions)
+			webhookGroup.POST("", webhookController.SaveSubscription)
+			webhookGroup.DELETE("/:id", webhookController.DeleteSubscription)
+			webhookGroup.GET("/deliveries", webhookController.ListDeliveries)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("fn", "test"))
+
+	log.InfoContext(WithRequestID(context.Background(), "abc-123"), "with id")
+	log.InfoContext(context.Background(), "without id")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var withID, withoutID map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &withID))
+	require.NoError(t, json.Unmarshal(lines[1], &withoutID))
+	assert.Equal(t, "abc-123", withID["request_id"])
+	assert.Equal(t, "test", withID["fn"])
+	assert.NotContains(t, withoutID, "request_id")
+}
+
+func TestHandlerAddsTraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	log.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "traced")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("7f3c9a1e-2b4d-4c8e-9f01-23456789abcd"))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("two words"))
+	assert.False(t, ValidRequestID("forged\nline"))
+	assert.False(t, ValidRequestID(string(bytes.Repeat([]byte("a"), maxRequestIDLength+1))))
+	assert.Len(t, NewRequestID(), 32)
+}
@@ -0,0 +1,186 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+var ErrInvalidOptions = errors.New("invalid qr code options")
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+// Options describe how a QR code is rendered. Size is the width of the image
+// in pixels and Margin is the quiet zone around the code in modules.
+type Options struct {
+	Format     string
+	Size       int
+	Level      string
+	Margin     int
+	Foreground string
+	Background string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       256,
+		Level:      "M",
+		Margin:     4,
+		Foreground: "000000",
+		Background: "ffffff",
+	}
+}
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return fmt.Errorf("%w: format must be png or svg", ErrInvalidOptions)
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("%w: size must be between %d and %d", ErrInvalidOptions, MinSize, MaxSize)
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return fmt.Errorf("%w: level must be one of L, M, Q, H", ErrInvalidOptions)
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+	if _, err := parseColor(o.Foreground); err != nil {
+		return fmt.Errorf("%w: fg %v", ErrInvalidOptions, err)
+	}
+	if _, err := parseColor(o.Background); err != nil {
+		return fmt.Errorf("%w: bg %v", ErrInvalidOptions, err)
+	}
+
+	return nil
+}
+
+// Normalize validates the options and returns them with the colors as
+// lowercase "rrggbb", so options rendering the same image are equal.
+func (o Options) Normalize() (Options, error) {
+	if err := o.Validate(); err != nil {
+		return o, err
+	}
+
+	fg, _ := parseColor(o.Foreground)
+	bg, _ := parseColor(o.Background)
+	o.Foreground = strings.TrimPrefix(hex(fg), "#")
+	o.Background = strings.TrimPrefix(hex(bg), "#")
+	return o, nil
+}
+
+// ContentType returns the MIME type of the rendered image.
+func (o Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode renders content as a QR code image in the requested format.
+func Encode(content string, opts Options) ([]byte, error) {
+	const fn = "qr.Encode"
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, levels[opts.Level])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	fg, _ := parseColor(opts.Foreground)
+	bg, _ := parseColor(opts.Background)
+
+	if opts.Format == FormatSVG {
+		return encodeSVG(bitmap, opts, fg, bg), nil
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rasterize(bitmap, opts, fg, bg)); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func rasterize(bitmap [][]bool, opts Options, fg, bg color.RGBA) image.Image {
+	modules := len(bitmap) + 2*opts.Margin
+	img := image.NewPaletted(image.Rect(0, 0, opts.Size, opts.Size), color.Palette{bg, fg})
+
+	// every pixel is mapped back to the module it falls in, so sizes that are
+	// not a multiple of the module count still fill the whole image
+	for y := 0; y < opts.Size; y++ {
+		row := y*modules/opts.Size - opts.Margin
+		for x := 0; x < opts.Size; x++ {
+			col := x*modules/opts.Size - opts.Margin
+			if row >= 0 && row < len(bitmap) && col >= 0 && col < len(bitmap) && bitmap[row][col] {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+func encodeSVG(bitmap [][]bool, opts Options, fg, bg color.RGBA) []byte {
+	modules := len(bitmap) + 2*opts.Margin
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, hex(bg))
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hex(fg))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return []byte(b.String())
+}
+
+// parseColor accepts colors as "rrggbb" with an optional leading "#".
+func parseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("color %q must be in rrggbb form", s)
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("color %q must be in rrggbb form", s)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,85 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodePNG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Size = 300
+	opts.Foreground = "#112233"
+
+	data, err := Encode("https://sho.rt/url/test", opts)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+
+	// the link fits a version 2 code of 25 modules, 33 with the quiet zone;
+	// the corner lies in the quiet zone and the finder pattern starts right after it
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+	r, g, b, _ = img.At(300*opts.Margin/33+2, 300*opts.Margin/33+2).RGBA()
+	assert.Equal(t, []uint32{0x1111, 0x2222, 0x3333}, []uint32{r, g, b})
+}
+
+func TestEncodeSVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = FormatSVG
+	opts.Margin = 0
+	opts.Background = "00ff00"
+
+	data, err := Encode("https://sho.rt/url/test", opts)
+	require.NoError(t, err)
+
+	svg := string(data)
+	assert.Contains(t, svg, `viewBox="0 0 25 25"`)
+	assert.Contains(t, svg, `fill="#00ff00"`)
+	assert.Contains(t, svg, "M0 0h1v1h-1z")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{name: "unknown format", modify: func(o *Options) { o.Format = "gif" }},
+		{name: "too small", modify: func(o *Options) { o.Size = MinSize - 1 }},
+		{name: "too large", modify: func(o *Options) { o.Size = MaxSize + 1 }},
+		{name: "unknown level", modify: func(o *Options) { o.Level = "X" }},
+		{name: "negative margin", modify: func(o *Options) { o.Margin = -1 }},
+		{name: "bad color", modify: func(o *Options) { o.Foreground = "red" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			assert.ErrorIs(t, opts.Validate(), ErrInvalidOptions)
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Foreground = "#FF0000"
+	opts.Background = "#ffffff"
+
+	normalized, err := opts.Normalize()
+	require.NoError(t, err)
+
+	expected := DefaultOptions()
+	expected.Foreground = "ff0000"
+	assert.Equal(t, expected, normalized)
+
+	opts.Level = "X"
+	_, err = opts.Normalize()
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
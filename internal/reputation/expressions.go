@@ -0,0 +1,211 @@
+package reputation
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Limits of the expressions of a URL set by the Safe Browsing lists.
+const (
+	maxHostSuffixes = 5 // the host itself and up to 4 of its parent domains
+	maxPathPrefixes = 4 // the root and up to 3 of the directories of the path
+)
+
+// expressions returns the host suffix and path prefix expressions of a URL,
+// the strings whose hashes the Safe Browsing lists hold, such as
+// "a.b.example.com/1/2.html?q=1", "b.example.com/1/" and "example.com/".
+// URLs without a host have none.
+func expressions(rawURL string) []string {
+	host, path, query, ok := canonicalize(rawURL)
+	if !ok {
+		return nil
+	}
+
+	paths := []string{path}
+	if query != "" {
+		paths = []string{path + "?" + query, path}
+	}
+	paths = append(paths, pathPrefixes(path)...)
+
+	var exprs []string
+	seen := map[string]bool{}
+	for _, h := range hostSuffixes(host) {
+		for _, p := range paths {
+			if expr := h + p; !seen[expr] {
+				seen[expr] = true
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+	return exprs
+}
+
+// canonicalize returns the canonical host, path and query of a URL: without
+// fragment, port and credentials, fully unescaped and then escaped again,
+// with a lowercase host free of stray dots and a path free of dot segments
+// and repeated slashes. The URL is split by hand as url.Parse refuses the
+// escaped and malformed hosts the lists are made to catch.
+func canonicalize(rawURL string) (host string, path string, query string, ok bool) {
+	rawURL = strings.TrimSpace(strings.NewReplacer("\t", "", "\r", "", "\n", "").Replace(rawURL))
+	rawURL, _, _ = strings.Cut(rawURL, "#")
+	_, rest, found := strings.Cut(rawURL, "://")
+	if !found {
+		return "", "", "", false
+	}
+
+	authority := rest
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		authority, rest = rest[:i], rest[i:]
+	} else {
+		rest = ""
+	}
+	rest, query, hasQuery := strings.Cut(rest, "?")
+
+	host = authority[strings.LastIndex(authority, "@")+1:]
+	if strings.HasPrefix(host, "[") {
+		host, _, _ = strings.Cut(host[1:], "]")
+	} else if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+
+	host = lowerASCII(unescape(host))
+	host = strings.Trim(host, ".")
+	for strings.Contains(host, "..") {
+		host = strings.ReplaceAll(host, "..", ".")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		host = addr.Unmap().String()
+	}
+	if host == "" {
+		return "", "", "", false
+	}
+
+	path = cleanPath(unescape(rest))
+	if hasQuery {
+		query = escape(unescape(query))
+	}
+
+	return escape(host), escape(path), query, true
+}
+
+// unescape decodes the percent escapes of s until none are left, keeping the
+// invalid ones as they are.
+func unescape(s string) string {
+	for {
+		var b strings.Builder
+		for i := 0; i < len(s); i++ {
+			if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+				b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+				i += 2
+				continue
+			}
+			b.WriteByte(s[i])
+		}
+		if b.String() == s {
+			return s
+		}
+		s = b.String()
+	}
+}
+
+// escape percent-encodes the control, space, non-ASCII, "#" and "%" bytes.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c >= 0x7f || c == '#' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// lowerASCII lowercases the ASCII letters only, strings.ToLower would replace
+// the bytes that are not UTF-8 and change their escapes.
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// cleanPath resolves the "." and ".." segments and collapses the runs of
+// slashes, keeping a trailing slash. Unlike path.Clean "/a/" stays "/a/".
+func cleanPath(p string) string {
+	var segments []string
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch part {
+		case "", ".":
+			if last && len(segments) > 0 {
+				segments = append(segments, "")
+			}
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+			if last && len(segments) > 0 {
+				segments = append(segments, "")
+			}
+		default:
+			segments = append(segments, part)
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// hostSuffixes returns the host and the parent domains formed from its last
+// 5 components, without the top-level domain. IP addresses have no parents.
+func hostSuffixes(host string) []string {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return []string{host}
+	}
+
+	suffixes := []string{host}
+	components := strings.Split(host, ".")
+	for i := max(len(components)-maxHostSuffixes, 1); i <= len(components)-2; i++ {
+		suffixes = append(suffixes, strings.Join(components[i:], "."))
+	}
+	return suffixes
+}
+
+// pathPrefixes returns the root and the first directories of the path, each
+// with a trailing slash.
+func pathPrefixes(path string) []string {
+	prefixes := []string{"/"}
+	directories := strings.Split(strings.Trim(path, "/"), "/")
+	if !strings.HasSuffix(path, "/") {
+		directories = directories[:len(directories)-1]
+	}
+
+	prefix := "/"
+	for _, directory := range directories {
+		if directory == "" || len(prefixes) == maxPathPrefixes {
+			break
+		}
+		prefix += directory + "/"
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
@@ -0,0 +1,195 @@
+package reputation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"url_shortener/internal/config"
+)
+
+// Formats of the hash list files.
+const (
+	FormatHex = "hex"
+	FormatRaw = "raw"
+)
+
+const (
+	minPrefixSize = 4
+	maxPrefixSize = sha256.Size
+)
+
+// defaultThreat is reported for the lists configured without a threat.
+const defaultThreat = "MALICIOUS"
+
+// HashList is a local list of SHA-256 hash prefixes of URL expressions, as
+// distributed by Safe Browsing. A URL is listed when the hash of one of its
+// expressions starts with a prefix of the list. Lists of 32-byte prefixes
+// are exact, shorter prefixes can match URLs that are not listed, since no
+// full hash is fetched to confirm the match.
+type HashList struct {
+	cfg config.ReputationList
+
+	mu       sync.RWMutex
+	prefixes map[string]struct{}
+	sizes    []int // the distinct lengths of the prefixes
+	modTime  time.Time
+}
+
+var (
+	_ Checker  = (*HashList)(nil) // check if HashList implements Checker interface
+	_ Reloader = (*HashList)(nil)
+)
+
+// LoadHashList reads the list file of cfg.
+func LoadHashList(cfg config.ReputationList) (*HashList, error) {
+	const fn = "reputation.hashlist.LoadHashList"
+
+	if cfg.Format == "" {
+		cfg.Format = FormatHex
+	}
+	if cfg.Threat == "" {
+		cfg.Threat = defaultThreat
+	}
+	if cfg.Format != FormatHex && cfg.Format != FormatRaw {
+		return nil, fmt.Errorf("%s: unknown format %q", fn, cfg.Format)
+	}
+	if cfg.Format == FormatRaw && (cfg.PrefixSize < minPrefixSize || cfg.PrefixSize > maxPrefixSize) {
+		return nil, fmt.Errorf("%s: prefix size must be between %d and %d bytes", fn, minPrefixSize, maxPrefixSize)
+	}
+
+	list := &HashList{cfg: cfg}
+	if err := list.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return list, nil
+}
+
+// Reload reads the list file again if it has been modified since it was
+// read. The list in use is kept if the file cannot be read.
+func (l *HashList) Reload() error {
+	const fn = "reputation.hashlist.Reload"
+
+	info, err := os.Stat(l.cfg.Path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	l.mu.RLock()
+	modified := !info.ModTime().Equal(l.modTime)
+	l.mu.RUnlock()
+	if !modified {
+		return nil
+	}
+
+	if err := l.load(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+func (l *HashList) load() error {
+	file, err := os.Open(l.cfg.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var prefixes [][]byte
+	if l.cfg.Format == FormatRaw {
+		prefixes, err = readRawPrefixes(file, l.cfg.PrefixSize)
+	} else {
+		prefixes, err = readHexPrefixes(file)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.cfg.Path, err)
+	}
+
+	set := make(map[string]struct{}, len(prefixes))
+	var sizes []int
+	for _, prefix := range prefixes {
+		set[string(prefix)] = struct{}{}
+		if !slices.Contains(sizes, len(prefix)) {
+			sizes = append(sizes, len(prefix))
+		}
+	}
+
+	l.mu.Lock()
+	l.prefixes, l.sizes, l.modTime = set, sizes, info.ModTime()
+	l.mu.Unlock()
+	return nil
+}
+
+// readHexPrefixes reads a hex-encoded prefix per line, skipping empty lines
+// and lines starting with "#".
+func readHexPrefixes(file *os.File) ([][]byte, error) {
+	var prefixes [][]byte
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		prefix, err := hex.DecodeString(text)
+		if err != nil || len(prefix) < minPrefixSize || len(prefix) > maxPrefixSize {
+			return nil, fmt.Errorf("line %d: invalid hash prefix %q", line, text)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefixes, nil
+}
+
+// readRawPrefixes splits the file into prefixes of size bytes.
+func readRawPrefixes(file *os.File, size int) ([][]byte, error) {
+	var data bytes.Buffer
+	if _, err := data.ReadFrom(file); err != nil {
+		return nil, err
+	}
+	if data.Len()%size != 0 {
+		return nil, fmt.Errorf("length %d is not a multiple of the prefix size %d", data.Len(), size)
+	}
+
+	prefixes := make([][]byte, 0, data.Len()/size)
+	for chunk := range slices.Chunk(data.Bytes(), size) {
+		prefixes = append(prefixes, chunk)
+	}
+	return prefixes, nil
+}
+
+func (l *HashList) Check(ctx context.Context, rawURL string) (Verdict, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, expr := range expressions(rawURL) {
+		if err := ctx.Err(); err != nil {
+			return Verdict{}, err
+		}
+
+		hash := sha256.Sum256([]byte(expr))
+		for _, size := range l.sizes {
+			if _, ok := l.prefixes[string(hash[:size])]; ok {
+				return Verdict{Threat: l.cfg.Threat}, nil
+			}
+		}
+	}
+
+	return Verdict{}, nil
+}
@@ -0,0 +1,123 @@
+package reputation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"url_shortener/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashPrefix(expr string, size int) []byte {
+	hash := sha256.Sum256([]byte(expr))
+	return hash[:size]
+}
+
+func writeList(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestHashList(t *testing.T) {
+	hexList := "# listed hosts and paths\n" +
+		hex.EncodeToString(hashPrefix("evil.example/", 4)) + "\n\n" +
+		hex.EncodeToString(hashPrefix("example.com/phish/", sha256.Size)) + "\n"
+	rawList := append(hashPrefix("evil.example/", 4), hashPrefix("example.com/phish/", 4)...)
+
+	lists := map[string]config.ReputationList{
+		"hex": {Path: writeList(t, "malware.hex", []byte(hexList)), Threat: "MALWARE"},
+		"raw": {Path: writeList(t, "malware.raw", rawList), Format: FormatRaw, PrefixSize: 4, Threat: "MALWARE"},
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedThreat string
+	}{
+		{name: "listed host", url: "https://evil.example/login", expectedThreat: "MALWARE"},
+		{name: "subdomain of a listed host", url: "https://www.EVIL.example/", expectedThreat: "MALWARE"},
+		{name: "listed path", url: "https://example.com/phish/bank.html?id=1", expectedThreat: "MALWARE"},
+		{name: "escaped listed path", url: "https://example.com/%70hish/", expectedThreat: "MALWARE"},
+		{name: "other path", url: "https://example.com/docs/"},
+		{name: "no host", url: "mailto:team@evil.example"},
+	}
+
+	for format, cfg := range lists {
+		list, err := LoadHashList(cfg)
+		require.NoError(t, err)
+
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				verdict, err := list.Check(context.Background(), tt.url)
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedThreat, verdict.Threat)
+			})
+		}
+	}
+}
+
+func TestHashListDefaultThreat(t *testing.T) {
+	list, err := LoadHashList(config.ReputationList{Path: writeList(t, "list.hex", []byte(hex.EncodeToString(hashPrefix("evil.example/", 8))))})
+	require.NoError(t, err)
+
+	verdict, err := list.Check(context.Background(), "http://evil.example/")
+	require.NoError(t, err)
+	assert.Equal(t, defaultThreat, verdict.Threat)
+}
+
+func TestHashListReload(t *testing.T) {
+	path := writeList(t, "list.hex", []byte("# empty\n"))
+	list, err := LoadHashList(config.ReputationList{Path: path})
+	require.NoError(t, err)
+
+	verdict, err := list.Check(context.Background(), "http://evil.example/")
+	require.NoError(t, err)
+	assert.False(t, verdict.Malicious())
+
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(hashPrefix("evil.example/", 4))), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, list.Reload())
+
+	verdict, err = list.Check(context.Background(), "http://evil.example/")
+	require.NoError(t, err)
+	assert.True(t, verdict.Malicious())
+
+	// a broken update keeps the list in use
+	require.NoError(t, os.WriteFile(path, []byte("not hex\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	assert.Error(t, list.Reload())
+
+	verdict, err = list.Check(context.Background(), "http://evil.example/")
+	require.NoError(t, err)
+	assert.True(t, verdict.Malicious())
+}
+
+func TestLoadHashListErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.ReputationList
+		expectedErr string
+	}{
+		{name: "missing file", cfg: config.ReputationList{Path: "testdata/missing.hex"}, expectedErr: "no such file"},
+		{name: "unknown format", cfg: config.ReputationList{Path: writeList(t, "list", nil), Format: "csv"}, expectedErr: `unknown format "csv"`},
+		{name: "raw without prefix size", cfg: config.ReputationList{Path: writeList(t, "list", nil), Format: FormatRaw}, expectedErr: "prefix size must be between 4 and 32 bytes"},
+		{name: "short hex prefix", cfg: config.ReputationList{Path: writeList(t, "list", []byte("# comment\nabcd\n"))}, expectedErr: `line 2: invalid hash prefix "abcd"`},
+		{name: "truncated raw prefix", cfg: config.ReputationList{Path: writeList(t, "list", []byte("12345")), Format: FormatRaw, PrefixSize: 4}, expectedErr: "length 5 is not a multiple of the prefix size 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadHashList(tt.cfg)
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+)
+
+// ErrUnavailable is returned when a checker of a fail-closed step cannot
+// tell whether a URL is malicious.
+var ErrUnavailable = errors.New("reputation check unavailable")
+
+// Verdict is the outcome of a check, the zero value for a clean URL.
+type Verdict struct {
+	// Threat is the kind of the URL, such as MALWARE or SOCIAL_ENGINEERING,
+	// empty if it is clean.
+	Threat string
+	// Source is the checker that listed the URL.
+	Source string
+}
+
+func (v Verdict) Malicious() bool {
+	return v.Threat != ""
+}
+
+// Checker tells whether URLs are known to be malicious.
+type Checker interface {
+	Check(ctx context.Context, rawURL string) (Verdict, error)
+}
+
+// CheckLink checks every destination of the link and returns the first
+// malicious verdict with the destination it is about.
+func CheckLink(ctx context.Context, checker Checker, link storage.URL) (Verdict, string, error) {
+	for _, destination := range link.Destinations() {
+		verdict, err := checker.Check(ctx, destination)
+		if err != nil {
+			return Verdict{}, destination, err
+		}
+		if verdict.Malicious() {
+			return verdict, destination, nil
+		}
+	}
+	return Verdict{}, "", nil
+}
+
+// Reloader is implemented by the checkers reading data that is updated
+// while the service runs.
+type Reloader interface {
+	Reload() error
+}
+
+// Step is a checker of a chain with the policy applied when it fails.
+type Step struct {
+	Name    string
+	Checker Checker
+	// Timeout bounds the check, zero disables it.
+	Timeout time.Duration
+	// FailOpen treats a URL as clean when the check fails or times out,
+	// otherwise the chain fails with ErrUnavailable.
+	FailOpen bool
+}
+
+// Chain runs its steps in order and returns the first malicious verdict.
+type Chain struct {
+	steps []Step
+	log   *slog.Logger
+}
+
+var (
+	_ Checker  = (*Chain)(nil) // check if Chain implements Checker interface
+	_ Reloader = (*Chain)(nil)
+)
+
+func NewChain(steps []Step, logger *slog.Logger) *Chain {
+	return &Chain{steps: steps, log: logger}
+}
+
+// New chains a hash list checker per configured list.
+func New(cfg config.Reputation, logger *slog.Logger) (*Chain, error) {
+	const fn = "reputation.reputation.New"
+
+	steps := make([]Step, 0, len(cfg.Lists))
+	for _, listCfg := range cfg.Lists {
+		list, err := LoadHashList(listCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		name := listCfg.Name
+		if name == "" {
+			name = filepath.Base(listCfg.Path)
+		}
+		steps = append(steps, Step{Name: name, Checker: list, Timeout: listCfg.Timeout, FailOpen: listCfg.FailOpen})
+	}
+
+	return NewChain(steps, logger), nil
+}
+
+func (c *Chain) Check(ctx context.Context, rawURL string) (Verdict, error) {
+	const fn = "reputation.reputation.Check"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	for _, step := range c.steps {
+		verdict, err := step.check(ctx, rawURL)
+		if err != nil {
+			if step.FailOpen {
+				log.WarnContext(ctx, "reputation check failed, treating the url as clean", slog.String("checker", step.Name), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+				continue
+			}
+			return Verdict{}, fmt.Errorf("%w: %s: %w", ErrUnavailable, step.Name, err)
+		}
+
+		if verdict.Malicious() {
+			if verdict.Source == "" {
+				verdict.Source = step.Name
+			}
+			return verdict, nil
+		}
+	}
+
+	return Verdict{}, nil
+}
+
+// check runs the checker of the step in its own goroutine, so that the
+// timeout also cuts checkers that do not watch their context.
+func (s Step) check(ctx context.Context, rawURL string) (Verdict, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		verdict Verdict
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		verdict, err := s.Checker.Check(ctx, rawURL)
+		done <- result{verdict: verdict, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.verdict, r.err
+	case <-ctx.Done():
+		return Verdict{}, ctx.Err()
+	}
+}
+
+// Reload reloads the checkers of the steps that read updated data.
+func (c *Chain) Reload() error {
+	var errs []error
+	for _, step := range c.steps {
+		if reloader, ok := step.Checker.(Reloader); ok {
+			if err := reloader.Reload(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", step.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,131 @@
+package reputation
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkerFunc adapts a function to the Checker interface.
+type checkerFunc func(ctx context.Context, rawURL string) (Verdict, error)
+
+func (f checkerFunc) Check(ctx context.Context, rawURL string) (Verdict, error) {
+	return f(ctx, rawURL)
+}
+
+func listing(threat string) Checker {
+	return checkerFunc(func(context.Context, string) (Verdict, error) { return Verdict{Threat: threat}, nil })
+}
+
+func failing(err error) Checker {
+	return checkerFunc(func(context.Context, string) (Verdict, error) { return Verdict{}, err })
+}
+
+// hanging ignores its context and never answers in time.
+var hanging = checkerFunc(func(context.Context, string) (Verdict, error) {
+	time.Sleep(time.Second)
+	return Verdict{}, nil
+})
+
+func TestChain(t *testing.T) {
+	down := errors.New("connection refused")
+
+	tests := []struct {
+		name            string
+		steps           []Step
+		expectedVerdict Verdict
+		expectedErr     error
+	}{
+		{
+			name:  "clean",
+			steps: []Step{{Name: "malware", Checker: listing("")}, {Name: "phishing", Checker: listing("")}},
+		},
+		{
+			name:            "first listing step wins",
+			steps:           []Step{{Name: "malware", Checker: listing("")}, {Name: "phishing", Checker: listing("SOCIAL_ENGINEERING")}, {Name: "other", Checker: listing("MALWARE")}},
+			expectedVerdict: Verdict{Threat: "SOCIAL_ENGINEERING", Source: "phishing"},
+		},
+		{
+			name: "source of the checker is kept",
+			steps: []Step{{Name: "remote", Checker: checkerFunc(func(context.Context, string) (Verdict, error) {
+				return Verdict{Threat: "MALWARE", Source: "vendor"}, nil
+			})}},
+			expectedVerdict: Verdict{Threat: "MALWARE", Source: "vendor"},
+		},
+		{
+			name:            "failing step fails open",
+			steps:           []Step{{Name: "remote", Checker: failing(down), FailOpen: true}, {Name: "malware", Checker: listing("MALWARE")}},
+			expectedVerdict: Verdict{Threat: "MALWARE", Source: "malware"},
+		},
+		{
+			name:        "failing step fails closed",
+			steps:       []Step{{Name: "remote", Checker: failing(down)}, {Name: "malware", Checker: listing("MALWARE")}},
+			expectedErr: down,
+		},
+		{
+			name:  "timed out step fails open",
+			steps: []Step{{Name: "slow", Checker: hanging, Timeout: 10 * time.Millisecond, FailOpen: true}},
+		},
+		{
+			name:        "timed out step fails closed",
+			steps:       []Step{{Name: "slow", Checker: hanging, Timeout: 10 * time.Millisecond}},
+			expectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := NewChain(tt.steps, slog.Default())
+
+			verdict, err := chain.Check(context.Background(), "https://example.com/")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, ErrUnavailable)
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedVerdict, verdict)
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	checker := checkerFunc(func(_ context.Context, rawURL string) (Verdict, error) {
+		if rawURL == "https://evil.example/" {
+			return Verdict{Threat: "MALWARE", Source: "malware"}, nil
+		}
+		return Verdict{}, nil
+	})
+
+	verdict, destination, err := CheckLink(context.Background(), checker, storage.URL{
+		URL:         "https://example.com/",
+		DeviceRules: []storage.DeviceRule{{OS: "android", URL: "https://evil.example/"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, Verdict{Threat: "MALWARE", Source: "malware"}, verdict)
+	assert.Equal(t, "https://evil.example/", destination)
+}
+
+func TestNew(t *testing.T) {
+	path := writeList(t, "phishing.hex", []byte(hex.EncodeToString(hashPrefix("evil.example/", 4))))
+
+	chain, err := New(config.Reputation{Lists: []config.ReputationList{{Path: path, Threat: "SOCIAL_ENGINEERING"}}}, slog.Default())
+	require.NoError(t, err)
+
+	verdict, err := chain.Check(context.Background(), "https://evil.example/")
+	require.NoError(t, err)
+	assert.Equal(t, Verdict{Threat: "SOCIAL_ENGINEERING", Source: "phishing.hex"}, verdict)
+
+	_, err = New(config.Reputation{Lists: []config.ReputationList{{Path: "testdata/missing.hex"}}}, slog.Default())
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package reputation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/postgres"
+)
+
+// scannerActor is the user the audit log records for the links blocked by
+// the rechecks.
+const scannerActor = "reputation-scanner"
+
+// defaultBatchSize replaces a batch size left at zero.
+const defaultBatchSize = 100
+
+// Scanner checks the stored links again and blocks those whose destination
+// has been listed since they were saved.
+type Scanner struct {
+	storage postgres.URLStorage
+	checker Checker
+	cfg     config.Reputation
+	log     *slog.Logger
+}
+
+func NewScanner(storage postgres.URLStorage, checker Checker, cfg config.Reputation, logger *slog.Logger) *Scanner {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	return &Scanner{storage: storage, checker: checker, cfg: cfg, log: logger}
+}
+
+// Run checks the links every configured interval until ctx is done.
+func (s *Scanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.RecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick reloads the updated lists and checks every link that is not blocked
+// yet. A link that cannot be checked is left as it is, whatever the policy
+// of the failing checker, until the next tick.
+func (s *Scanner) Tick(ctx context.Context) {
+	const fn = "reputation.scanner.Tick"
+	log := s.log.With(
+		slog.String("fn", fn),
+	)
+
+	if reloader, ok := s.checker.(Reloader); ok {
+		if err := reloader.Reload(); err != nil {
+			log.Error("failed to reload the reputation lists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+	}
+
+	var checked, blocked int
+	var lastID int64
+	for {
+		afterID := lastID
+		links, err := s.storage.ListURLs(ctx, storage.URLFilter{AllDomains: true, Limit: s.cfg.BatchSize, AfterID: &afterID})
+		if err != nil {
+			log.Error("failed to list links", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return
+		}
+
+		for _, link := range links {
+			if ctx.Err() != nil {
+				return
+			}
+			lastID = link.ID
+			if link.BlockedAt != nil {
+				continue
+			}
+
+			checked++
+			if s.check(ctx, log, link) {
+				blocked++
+			}
+		}
+
+		if len(links) < s.cfg.BatchSize {
+			break
+		}
+	}
+
+	log.Info("links have been checked again", slog.Int("checked", checked), slog.Int("blocked", blocked))
+}
+
+// check blocks the link if one of its destinations is listed and reports
+// whether it did.
+func (s *Scanner) check(ctx context.Context, log *slog.Logger, link storage.URL) bool {
+	verdict, destination, err := CheckLink(ctx, s.checker, link)
+	if err != nil {
+		log.Warn("failed to check link", slog.String("alias", link.Alias), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return false
+	}
+	if !verdict.Malicious() {
+		return false
+	}
+
+	reason := fmt.Sprintf("destination flagged as %s by %s", verdict.Threat, verdict.Source)
+	if _, err := s.storage.BlockURL(ctx, link.Domain, link.Alias, reason, storage.Actor{User: scannerActor}); err != nil {
+		log.Error("failed to block link", slog.String("alias", link.Alias), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return false
+	}
+
+	log.Warn("link has been blocked", slog.String("alias", link.Alias), slog.String("destination", destination), slog.String("threat", verdict.Threat), slog.String("source", verdict.Source))
+	return true
+}
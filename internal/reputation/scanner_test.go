@@ -0,0 +1,111 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var evilChecker = checkerFunc(func(_ context.Context, rawURL string) (Verdict, error) {
+	switch rawURL {
+	case "https://evil.example/":
+		return Verdict{Threat: "MALWARE", Source: "malware"}, nil
+	case "https://flaky.example/":
+		return Verdict{}, ErrUnavailable
+	}
+	return Verdict{}, nil
+})
+
+func TestScannerTick(t *testing.T) {
+	blockedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	actor := storage.Actor{User: scannerActor}
+
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Limit: 2, AfterID: int64Ptr(0)}).Return([]storage.URL{
+		{ID: 1, Alias: "clean", URL: "https://example.com/"},
+		{ID: 2, Alias: "evil", Domain: "go.brand.com", URL: "https://evil.example/"},
+	}, nil)
+	mockStorage.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Limit: 2, AfterID: int64Ptr(2)}).Return([]storage.URL{
+		{ID: 3, Alias: "already-blocked", URL: "https://evil.example/", BlockedAt: &blockedAt},
+		{ID: 5, Alias: "flaky", URL: "https://flaky.example/"},
+	}, nil)
+	mockStorage.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Limit: 2, AfterID: int64Ptr(5)}).Return([]storage.URL{
+		{ID: 8, Alias: "variant", URL: "https://example.com/", Variants: []storage.Variant{{Name: "b", URL: "https://evil.example/", Weight: 1}}},
+	}, nil)
+	mockStorage.On("BlockURL", mock.Anything, "go.brand.com", "evil", "destination flagged as MALWARE by malware", actor).Return(storage.URL{}, nil)
+	mockStorage.On("BlockURL", mock.Anything, "", "variant", "destination flagged as MALWARE by malware", actor).Return(storage.URL{}, errors.New("connection refused"))
+
+	scanner := NewScanner(mockStorage, evilChecker, config.Reputation{BatchSize: 2}, slog.Default())
+	scanner.Tick(context.Background())
+
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNumberOfCalls(t, "BlockURL", 2)
+}
+
+func TestScannerTickListFailure(t *testing.T) {
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("ListURLs", mock.Anything, storage.URLFilter{AllDomains: true, Limit: defaultBatchSize, AfterID: int64Ptr(0)}).Return(nil, errors.New("connection refused"))
+
+	scanner := NewScanner(mockStorage, evilChecker, config.Reputation{}, slog.Default())
+	scanner.Tick(context.Background())
+
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "BlockURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScannerTickLinksChangedDuringScan(t *testing.T) {
+	links := []storage.URL{
+		{ID: 1, Alias: "a", URL: "https://a.example/"},
+		{ID: 2, Alias: "b", URL: "https://b.example/"},
+		{ID: 3, Alias: "c", URL: "https://c.example/"},
+		{ID: 4, Alias: "d", URL: "https://d.example/"},
+		{ID: 5, Alias: "e", URL: "https://e.example/"},
+	}
+
+	var batches int
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("ListURLs", mock.Anything, mock.Anything).Return(func(_ context.Context, filter storage.URLFilter) []storage.URL {
+		batches++
+		if batches == 2 {
+			// a checked link is deleted and a new link is added between two batches
+			links = slices.DeleteFunc(links, func(link storage.URL) bool { return link.ID == 1 })
+			links = append(links, storage.URL{ID: 6, Alias: "f", URL: "https://f.example/"})
+		}
+
+		var batch []storage.URL
+		for _, link := range links {
+			if link.ID > *filter.AfterID && len(batch) < filter.Limit {
+				batch = append(batch, link)
+			}
+		}
+		return batch
+	}, nil)
+
+	var checked []string
+	checker := checkerFunc(func(_ context.Context, rawURL string) (Verdict, error) {
+		checked = append(checked, rawURL)
+		return Verdict{}, nil
+	})
+
+	scanner := NewScanner(mockStorage, checker, config.Reputation{BatchSize: 2}, slog.Default())
+	scanner.Tick(context.Background())
+
+	assert.Equal(t, []string{
+		"https://a.example/", "https://b.example/", "https://c.example/",
+		"https://d.example/", "https://e.example/", "https://f.example/",
+	}, checked)
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
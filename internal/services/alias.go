@@ -0,0 +1,75 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// aliasAlphabet is the characters of generated aliases, without those easily
+// mistaken for one another such as 0 and O or 1 and l. foldedAliasAlphabet
+// replaces it when the alias policy folds the case.
+const (
+	aliasAlphabet       = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	foldedAliasAlphabet = "23456789abcdefghijkmnpqrstuvwxyz"
+)
+
+const (
+	generatedAliasLength = 7
+	// maxAliasAttempts bounds the aliases drawn for a link when the drawn
+	// ones are taken.
+	maxAliasAttempts = 5
+	// maxAliasDraws bounds the aliases drawn for one attempt when the drawn
+	// ones contain a blocked word.
+	maxAliasDraws = 100
+)
+
+// generateAlias draws a random alias for a link saved without one, drawing
+// again the aliases that contain a blocked word.
+func (c *urlService) generateAlias() (string, error) {
+	alphabet := aliasAlphabet
+	if c.aliases.foldCase {
+		alphabet = foldedAliasAlphabet
+	}
+
+	alias := make([]byte, generatedAliasLength)
+	for range maxAliasDraws {
+		for i := range alias {
+			alias[i] = alphabet[c.randIntN(len(alphabet))]
+		}
+		if !c.aliases.blocked(string(alias)) {
+			return string(alias), nil
+		}
+	}
+	return "", errors.New("every drawn alias contains a blocked word")
+}
+
+// destinationHash identifies a destination regardless of the case of its
+// scheme and host, a default port, an empty path or the order of its query
+// parameters.
+func destinationHash(destination string) string {
+	normalized := destination
+	if u, err := url.Parse(destination); err == nil {
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = strings.TrimSuffix(u.Host, ":"+port)
+		}
+		if u.Path == "" && u.Opaque == "" {
+			u.Path = "/"
+		}
+		if u.RawQuery != "" {
+			pairs := strings.Split(u.RawQuery, "&")
+			slices.Sort(pairs)
+			u.RawQuery = strings.Join(pairs, "&")
+		}
+		u.ForceQuery = false
+		normalized = u.String()
+	}
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"url_shortener/internal/config"
+)
+
+// reservedAliases collide with the API, health and metrics routes when short
+// links are served at the root path.
+var reservedAliases = []string{"api", "url", "tags", "domains", "webhooks", "audit", "health", "healthz", "metrics", "favicon.ico", "robots.txt"}
+
+// Defaults of the alias policy for the settings left empty.
+const (
+	defaultAliasCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_."
+	defaultAliasMinLength  = 1
+	defaultAliasMaxLength  = 64
+)
+
+// confusables map the characters that look like a letter to that letter,
+// digits and symbols as well as Cyrillic and Greek homoglyphs.
+var confusables = map[rune]rune{
+	'0': 'o', '1': 'l', 'i': 'l', '|': 'l', '!': 'l', '3': 'e', '4': 'a', '@': 'a', '5': 's', '$': 's', '7': 't', '8': 'b', '9': 'g',
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x',
+	'і': 'l', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'һ': 'h', 'ԛ': 'q', 'ԝ': 'w',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+}
+
+// scripts are the writing systems whose letters pass for one another, an
+// alias mixing them is a look-alike of an alias written in one of them.
+var scripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian, unicode.Cherokee}
+
+// AliasPolicy checks the custom aliases of new links. The zero value of every
+// setting of config.Aliases falls back to a permissive default.
+type AliasPolicy struct {
+	characters       string
+	minLength        int
+	maxLength        int
+	foldCase         bool
+	reserved         []string
+	blocklist        []string
+	allowConfusables bool
+}
+
+// NewAliasPolicy creates the policy of cfg, reading its blocklist file.
+func NewAliasPolicy(cfg config.Aliases) (*AliasPolicy, error) {
+	const fn = "services.alias_policy.NewAliasPolicy"
+
+	policy := defaultAliasPolicy()
+	if cfg.Characters != "" {
+		policy.characters = cfg.Characters
+	}
+	if cfg.MinLength > 0 {
+		policy.minLength = cfg.MinLength
+	}
+	if cfg.MaxLength > 0 {
+		policy.maxLength = cfg.MaxLength
+	}
+	if policy.minLength > policy.maxLength {
+		return nil, fmt.Errorf("%s: min length %d is above max length %d", fn, policy.minLength, policy.maxLength)
+	}
+	policy.foldCase = cfg.FoldCase
+	policy.allowConfusables = cfg.AllowConfusables
+	for _, word := range cfg.Reserved {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			policy.reserved = append(policy.reserved, word)
+		}
+	}
+
+	if cfg.BlocklistPath != "" {
+		blocklist, err := readBlocklist(cfg.BlocklistPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		policy.blocklist = blocklist
+	}
+
+	return policy, nil
+}
+
+// defaultAliasPolicy is the policy of an empty configuration.
+func defaultAliasPolicy() *AliasPolicy {
+	return &AliasPolicy{
+		characters: defaultAliasCharacters,
+		minLength:  defaultAliasMinLength,
+		maxLength:  defaultAliasMaxLength,
+		reserved:   slices.Clone(reservedAliases),
+	}
+}
+
+// readBlocklist reads a word per line, skipping empty lines and lines
+// starting with "#".
+func readBlocklist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// Check validates a custom alias and returns it as it is stored, lowercased
+// when the policy folds the case. All the violations are reported at once.
+func (p *AliasPolicy) Check(alias string) (string, error) {
+	alias = p.Fold(alias)
+
+	var reasons []string
+	if length := len([]rune(alias)); length < p.minLength || length > p.maxLength {
+		reasons = append(reasons, fmt.Sprintf("must be between %d and %d characters", p.minLength, p.maxLength))
+	}
+
+	var invalid []rune
+	for _, r := range alias {
+		if r != '/' && !strings.ContainsRune(p.characters, r) && !slices.Contains(invalid, r) {
+			invalid = append(invalid, r)
+		}
+	}
+	if len(invalid) > 0 {
+		reasons = append(reasons, fmt.Sprintf("must not contain %q", string(invalid)))
+	}
+
+	// only the first segment of an alias holding slashes reaches the router
+	if !validAliasPath(alias) {
+		reasons = append(reasons, "must not have empty, . or .. segments")
+	}
+	first, _, _ := strings.Cut(alias, "/")
+	if word, ok := p.reservedWord(first); ok {
+		reasons = append(reasons, fmt.Sprintf("is reserved as %s", word))
+	}
+
+	if p.blocked(alias) {
+		reasons = append(reasons, "contains a blocked word")
+	}
+	if !p.allowConfusables && mixedScripts(alias) {
+		reasons = append(reasons, "mixes look-alike letters of different scripts")
+	}
+
+	if len(reasons) > 0 {
+		return alias, fmt.Errorf("%w: alias %s %s", ErrInvalidInput, alias, strings.Join(reasons, ", "))
+	}
+	return alias, nil
+}
+
+// Fold returns the alias links are looked up with, lowercased when the policy
+// folds the case.
+func (p *AliasPolicy) Fold(alias string) string {
+	if p.foldCase {
+		return strings.ToLower(alias)
+	}
+	return alias
+}
+
+// reservedWord returns the reserved word segment is or, unless confusables
+// are allowed, looks like.
+func (p *AliasPolicy) reservedWord(segment string) (string, bool) {
+	lower := strings.ToLower(segment)
+	for _, word := range p.reserved {
+		if lower == word || (!p.allowConfusables && skeleton(lower) == skeleton(word)) {
+			return word, true
+		}
+	}
+	return "", false
+}
+
+// blocked reports whether the alias contains a word of the blocklist, also
+// when written with look-alike characters or with separators in between
+// unless confusables are allowed.
+func (p *AliasPolicy) blocked(alias string) bool {
+	lower := strings.ToLower(alias)
+	for _, word := range p.blocklist {
+		if strings.Contains(lower, word) || (!p.allowConfusables && strings.Contains(skeleton(lower), skeleton(word))) {
+			return true
+		}
+	}
+	return false
+}
+
+// skeleton reduces text to the letters it looks like, dropping separators,
+// so that "p4y-pa1" and "paypal" have the same skeleton.
+func skeleton(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if strings.ContainsRune("-_./", r) {
+			continue
+		}
+		if letter, ok := confusables[r]; ok {
+			r = letter
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// mixedScripts reports whether the letters of the alias come from more than
+// one of the scripts sharing look-alike letters.
+func mixedScripts(alias string) bool {
+	var found *unicode.RangeTable
+	for _, r := range alias {
+		for _, script := range scripts {
+			if !unicode.Is(script, r) {
+				continue
+			}
+			if found != nil && found != script {
+				return true
+			}
+			found = script
+		}
+	}
+	return false
+}
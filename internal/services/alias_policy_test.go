@@ -0,0 +1,68 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"url_shortener/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasPolicy(t *testing.T) {
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(blocklist, []byte("# words aliases must not contain\nscam\n\nPhish\n"), 0o600))
+
+	tests := []struct {
+		name          string
+		cfg           config.Aliases
+		alias         string
+		expectedAlias string
+		expectedErr   string
+	}{
+		{name: "valid alias", alias: "Spring-Sale_2025", expectedAlias: "Spring-Sale_2025"},
+		{name: "prefix alias", alias: "docs/v2", expectedAlias: "docs/v2"},
+		{name: "case is folded", cfg: config.Aliases{FoldCase: true}, alias: "Spring-Sale", expectedAlias: "spring-sale"},
+		{name: "space", alias: "spring sale", expectedErr: `must not contain " "`},
+		{name: "characters outside of the configured ones", cfg: config.Aliases{Characters: "abc"}, alias: "abd!", expectedErr: `must not contain "d!"`},
+		{name: "too short", cfg: config.Aliases{MinLength: 4}, alias: "abc", expectedErr: "must be between 4 and 64 characters"},
+		{name: "too long", cfg: config.Aliases{MaxLength: 5}, alias: "abcdef", expectedErr: "must be between 1 and 5 characters"},
+		{name: "empty segment", alias: "docs//v2", expectedErr: "must not have empty, . or .. segments"},
+		{name: "reserved route", alias: "Metrics", expectedErr: "is reserved as metrics"},
+		{name: "reserved first segment", alias: "api/docs", expectedErr: "is reserved as api"},
+		{name: "configured reserved word", cfg: config.Aliases{Reserved: []string{" Login "}}, alias: "login", expectedErr: "is reserved as login"},
+		{name: "look-alike of a reserved word", alias: "4p1", expectedErr: "is reserved as api"},
+		{name: "look-alikes allowed", cfg: config.Aliases{AllowConfusables: true}, alias: "4p1", expectedAlias: "4p1"},
+		{name: "blocked word", cfg: config.Aliases{BlocklistPath: blocklist}, alias: "free-phishing", expectedErr: "contains a blocked word"},
+		{name: "blocked word with look-alikes", cfg: config.Aliases{BlocklistPath: blocklist}, alias: "5c-4m", expectedErr: "contains a blocked word"},
+		{name: "mixed scripts", cfg: config.Aliases{Characters: "abcdefghijklmnopqrstuvwxyzа"}, alias: "pаypal", expectedErr: "mixes look-alike letters of different scripts"},
+		{name: "single other script", cfg: config.Aliases{Characters: "абвгдежзийклмнопрстуфхцчшщъыьэюя"}, alias: "привет", expectedAlias: "привет"},
+		{name: "all violations are reported", cfg: config.Aliases{MaxLength: 3}, alias: "url ", expectedErr: `alias url  must be between 1 and 3 characters, must not contain " "`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewAliasPolicy(tt.cfg)
+			require.NoError(t, err)
+
+			alias, err := policy.Check(tt.alias)
+
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidInput)
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAlias, alias)
+		})
+	}
+}
+
+func TestNewAliasPolicyErrors(t *testing.T) {
+	_, err := NewAliasPolicy(config.Aliases{BlocklistPath: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+
+	_, err = NewAliasPolicy(config.Aliases{MinLength: 10, MaxLength: 5})
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestinationHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{name: "case of scheme and host", a: "HTTPS://Example.COM/Docs", b: "https://example.com/Docs", equal: true},
+		{name: "default port", a: "https://example.com:443/", b: "https://example.com/", equal: true},
+		{name: "empty path", a: "https://example.com", b: "https://example.com/", equal: true},
+		{name: "order of the query", a: "https://example.com/?b=2&a=1", b: "https://example.com/?a=1&b=2", equal: true},
+		{name: "case of the path", a: "https://example.com/Docs", b: "https://example.com/docs"},
+		{name: "other port", a: "https://example.com:8443/", b: "https://example.com/"},
+		{name: "fragment", a: "https://example.com/#top", b: "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, destinationHash(tt.a) == destinationHash(tt.b))
+		})
+	}
+}
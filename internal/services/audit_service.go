@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/postgres"
+)
+
+type AuditService interface {
+	ListAudit(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEntry, error)
+}
+
+type auditService struct {
+	auditStorage postgres.AuditStorage
+	log          *slog.Logger
+}
+
+func NewAuditService(storage postgres.AuditStorage, logger *slog.Logger) AuditService {
+	return &auditService{auditStorage: storage, log: logger}
+}
+
+func (c *auditService) ListAudit(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEntry, error) {
+	const fn = "services.audit_service.ListAudit"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	if filter.Limit < 0 || filter.Limit > maxListLimit || filter.Offset < 0 {
+		log.ErrorContext(ctx, "invalid pagination", slog.Int("limit", filter.Limit), slog.Int("offset", filter.Offset))
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d and offset must not be negative", ErrInvalidInput, maxListLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+
+	if filter.Action != "" && !slices.Contains(storage.AuditActions, filter.Action) {
+		log.ErrorContext(ctx, "unknown audit action", slog.String("action", filter.Action))
+		return nil, fmt.Errorf("%w: action must be one of %s", ErrInvalidInput, strings.Join(storage.AuditActions, ", "))
+	}
+
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		log.ErrorContext(ctx, "invalid time range", slog.Time("since", *filter.Since), slog.Time("until", *filter.Until))
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidInput)
+	}
+
+	entries, err := c.auditStorage.ListAudit(ctx, filter)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to list the audit log", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	return entries, nil
+}
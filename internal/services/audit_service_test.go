@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListAudit(t *testing.T) {
+	since := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		filter      storage.AuditFilter
+		mockSetup   func(*mocks.AuditStorage)
+		expectedErr error
+	}{
+		{
+			name:   "default limit",
+			filter: storage.AuditFilter{Actor: "user", Action: storage.AuditLinkDelete, Since: &since, Until: &until},
+			mockSetup: func(m *mocks.AuditStorage) {
+				m.On("ListAudit", mock.Anything, storage.AuditFilter{Actor: "user", Action: storage.AuditLinkDelete, Since: &since, Until: &until, Limit: defaultListLimit}).
+					Return([]storage.AuditEntry{}, nil)
+			},
+		},
+		{
+			name:        "unknown action",
+			filter:      storage.AuditFilter{Action: "link.visit"},
+			mockSetup:   func(m *mocks.AuditStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "empty time range",
+			filter:      storage.AuditFilter{Since: &until, Until: &since},
+			mockSetup:   func(m *mocks.AuditStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "negative offset",
+			filter:      storage.AuditFilter{Offset: -1},
+			mockSetup:   func(m *mocks.AuditStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.AuditStorage)
+			tt.mockSetup(mockStorage)
+
+			_, err := NewAuditService(mockStorage, slog.Default()).ListAudit(context.Background(), tt.filter)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+)
+
+// privateSuffixes are the names that only resolve inside a local network.
+var privateSuffixes = []string{"localhost", "local", "internal", "lan", "home.arpa"}
+
+// sharedAddressSpace is the carrier-grade NAT range, private to the networks
+// of the providers.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// resolveTimeout bounds the lookup of the host of a destination.
+const resolveTimeout = 2 * time.Second
+
+// hostResolver looks up the addresses of a host, net.Resolver is one.
+type hostResolver interface {
+	LookupNetIP(ctx context.Context, network string, host string) ([]netip.Addr, error)
+}
+
+// hostPattern is an entry of the allow or deny list of destinations.
+type hostPattern struct {
+	entry    string
+	host     string // exact host, or the parent domain of a wildcard
+	wildcard bool
+	prefix   netip.Prefix // set for addresses and CIDRs
+}
+
+// DestinationPolicy checks the destinations of new and updated links against
+// allow and deny lists of hosts and refuses private networks.
+type DestinationPolicy struct {
+	allow        []hostPattern
+	deny         []hostPattern
+	allowPrivate bool
+	// resolver looks up the hosts of destinations to refuse the names of
+	// private addresses, with a nil resolver names are not looked up.
+	resolver hostResolver
+}
+
+// NewDestinationPolicy creates the policy of cfg.
+func NewDestinationPolicy(cfg config.Destinations) (*DestinationPolicy, error) {
+	const fn = "services.destination_policy.NewDestinationPolicy"
+
+	allow, err := parseHostPatterns(cfg.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("%s: allow: %w", fn, err)
+	}
+	deny, err := parseHostPatterns(cfg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("%s: deny: %w", fn, err)
+	}
+
+	return &DestinationPolicy{allow: allow, deny: deny, allowPrivate: cfg.AllowPrivate, resolver: net.DefaultResolver}, nil
+}
+
+func parseHostPatterns(entries []string) ([]hostPattern, error) {
+	patterns := make([]hostPattern, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			patterns = append(patterns, hostPattern{entry: entry, prefix: prefix.Masked()})
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			patterns = append(patterns, hostPattern{entry: entry, prefix: netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())})
+			continue
+		}
+
+		host, wildcard := strings.CutPrefix(entry, "*.")
+		host = strings.TrimSuffix(host, ".")
+		if host == "" || strings.ContainsAny(host, "*/:") {
+			return nil, fmt.Errorf("invalid host %q", entry)
+		}
+		patterns = append(patterns, hostPattern{entry: entry, host: host, wildcard: wildcard})
+	}
+	return patterns, nil
+}
+
+// matches reports whether the pattern covers the host, addr is the address
+// of a host that is an IP literal.
+func (p hostPattern) matches(host string, addr netip.Addr, isAddr bool) bool {
+	if p.prefix.IsValid() {
+		return isAddr && p.prefix.Contains(addr)
+	}
+	if isAddr {
+		return false
+	}
+	if p.wildcard {
+		return strings.HasSuffix(host, "."+p.host)
+	}
+	return host == p.host
+}
+
+// CheckLink checks the default destination of the link and those of its
+// targeting rules and variants.
+func (p *DestinationPolicy) CheckLink(ctx context.Context, link storage.URL) error {
+	for _, destination := range link.Destinations() {
+		if err := p.Check(ctx, destination); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports why the destination is refused, nil if it is not. Only http
+// and https destinations with a host are redirected to, as the host is what
+// the lists and the private network checks look at.
+func (p *DestinationPolicy) Check(ctx context.Context, destination string) error {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return fmt.Errorf("%w: destination %s is not a valid URL", ErrInvalidInput, destination)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("%w: destination %s must use http or https", ErrInvalidInput, destination)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: destination %s has no host", ErrInvalidInput, destination)
+	}
+	addr, isAddr := parseHostAddr(host)
+
+	if !p.allowPrivate && isPrivateHost(host, addr, isAddr) {
+		return fmt.Errorf("%w: destination %s is on a private network", ErrInvalidInput, destination)
+	}
+	if !p.allowPrivate && !isAddr && p.resolvesToPrivate(ctx, host) {
+		return fmt.Errorf("%w: destination %s resolves to a private network", ErrInvalidInput, destination)
+	}
+	for _, pattern := range p.deny {
+		if pattern.matches(host, addr, isAddr) {
+			return fmt.Errorf("%w: destination %s is denied by %s", ErrInvalidInput, destination, pattern.entry)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, pattern := range p.allow {
+		if pattern.matches(host, addr, isAddr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: destination %s is not on the allow list", ErrInvalidInput, destination)
+}
+
+// resolvesToPrivate reports whether any address of the host is private, as
+// names such as 127.0.0.1.nip.io point public names at local networks. Hosts
+// that do not resolve are left to the redirect, they reach no network.
+func (p *DestinationPolicy) resolvesToPrivate(ctx context.Context, host string) bool {
+	if p.resolver == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := p.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if isPrivateAddr(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateHost reports whether the host only makes sense inside a local
+// network: private addresses, names without a dot and the names reserved
+// for local use.
+func isPrivateHost(host string, addr netip.Addr, isAddr bool) bool {
+	if isAddr {
+		return isPrivateAddr(addr)
+	}
+	if !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range privateSuffixes {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateAddr reports whether the address is loopback, private,
+// link-local, unspecified or in the shared address space.
+func isPrivateAddr(addr netip.Addr) bool {
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr)
+}
+
+// parseHostAddr parses a host that is an IP literal, including the IPv4
+// forms browsers accept such as "2130706433" or "0x7f.1" for 127.0.0.1.
+func parseHostAddr(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+	return parseLooseIPv4(host)
+}
+
+// parseLooseIPv4 parses one to four parts in decimal, octal with a leading 0
+// or hexadecimal with a leading 0x, the last part filling the bytes left.
+func parseLooseIPv4(host string) (netip.Addr, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	var ip uint64
+	for i, part := range parts {
+		value, ok := parseIPv4Part(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		if i < len(parts)-1 {
+			if value > 255 {
+				return netip.Addr{}, false
+			}
+			ip |= value << (8 * (3 - i))
+			continue
+		}
+		if value >= 1<<(8*(4-i)) {
+			return netip.Addr{}, false
+		}
+		ip |= value
+	}
+
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case strings.HasPrefix(part, "0x"):
+		part, base = part[2:], 16
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+	if part == "" || strings.ContainsAny(part, "+-_") {
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(part, base, 32)
+	return value, err == nil
+}
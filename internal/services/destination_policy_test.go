@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticResolver answers lookups from a fixed table, other hosts do not exist.
+type staticResolver map[string][]netip.Addr
+
+func (r staticResolver) LookupNetIP(_ context.Context, _ string, host string) ([]netip.Addr, error) {
+	if addrs, ok := r[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+var testResolver = staticResolver{
+	"example.com":      {netip.MustParseAddr("93.184.215.14")},
+	"127.0.0.1.nip.io": {netip.MustParseAddr("127.0.0.1")},
+	"localtest.me":     {netip.MustParseAddr("::1")},
+	"dual.example.org": {netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("::ffff:10.0.0.1")},
+}
+
+func TestDestinationPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Destinations
+		destination string
+		expectedErr string
+	}{
+		{name: "public host", destination: "https://example.com/page"},
+		{name: "mailto scheme", destination: "mailto:team@example.com", expectedErr: "must use http or https"},
+		{name: "javascript scheme", destination: "javascript:alert(document.cookie)", expectedErr: "must use http or https"},
+		{name: "data scheme", destination: "data:text/html,<script>alert(1)</script>", expectedErr: "must use http or https"},
+		{name: "no host", destination: "http:///x", expectedErr: "has no host"},
+		{name: "scheme in upper case", destination: "HTTPS://example.com/"},
+		{name: "localhost", destination: "http://localhost:8080/admin", expectedErr: "is on a private network"},
+		{name: "local name", destination: "http://printer.local/", expectedErr: "is on a private network"},
+		{name: "name without a dot", destination: "http://intranet/", expectedErr: "is on a private network"},
+		{name: "loopback address", destination: "http://127.0.0.1/", expectedErr: "is on a private network"},
+		{name: "private address", destination: "http://192.168.1.10/", expectedErr: "is on a private network"},
+		{name: "metadata address", destination: "http://169.254.169.254/latest/meta-data", expectedErr: "is on a private network"},
+		{name: "shared address space", destination: "http://100.64.0.1/", expectedErr: "is on a private network"},
+		{name: "loopback IPv6", destination: "http://[::1]/", expectedErr: "is on a private network"},
+		{name: "mapped IPv4", destination: "http://[::ffff:10.0.0.1]/", expectedErr: "is on a private network"},
+		{name: "decimal IPv4", destination: "http://2130706433/", expectedErr: "is on a private network"},
+		{name: "hexadecimal IPv4", destination: "http://0x7f.1/", expectedErr: "is on a private network"},
+		{name: "octal IPv4", destination: "http://0300.0250.0.1/", expectedErr: "is on a private network"},
+		{name: "public address", destination: "http://203.0.113.7/"},
+		{name: "name of a loopback address", destination: "http://127.0.0.1.nip.io/", expectedErr: "resolves to a private network"},
+		{name: "name of a loopback IPv6 address", destination: "http://LOCALTEST.me:8080/", expectedErr: "resolves to a private network"},
+		{name: "name with one private address", destination: "https://dual.example.org/", expectedErr: "resolves to a private network"},
+		{name: "name that does not resolve", destination: "https://unknown.example.net/"},
+		{name: "private name allowed", cfg: config.Destinations{AllowPrivate: true}, destination: "http://127.0.0.1.nip.io/"},
+		{name: "private allowed", cfg: config.Destinations{AllowPrivate: true}, destination: "http://localhost:8080/"},
+		{name: "denied host", cfg: config.Destinations{Deny: []string{"Evil.example"}}, destination: "https://EVIL.example./", expectedErr: "is denied by evil.example"},
+		{name: "denied subdomain", cfg: config.Destinations{Deny: []string{"*.evil.example"}}, destination: "https://a.b.evil.example/", expectedErr: "is denied by *.evil.example"},
+		{name: "wildcard does not match its domain", cfg: config.Destinations{Deny: []string{"*.evil.example"}}, destination: "https://evil.example/"},
+		{name: "denied CIDR", cfg: config.Destinations{Deny: []string{"203.0.113.0/24"}}, destination: "http://203.0.113.7/", expectedErr: "is denied by 203.0.113.0/24"},
+		{name: "deny wins over allow", cfg: config.Destinations{Allow: []string{"*.example.com"}, Deny: []string{"bad.example.com"}}, destination: "https://bad.example.com/", expectedErr: "is denied by bad.example.com"},
+		{name: "allowed host", cfg: config.Destinations{Allow: []string{"*.example.com"}}, destination: "https://shop.example.com/"},
+		{name: "host not allowed", cfg: config.Destinations{Allow: []string{"*.example.com"}}, destination: "https://example.org/", expectedErr: "is not on the allow list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewDestinationPolicy(tt.cfg)
+			require.NoError(t, err)
+			policy.resolver = testResolver
+
+			err = policy.Check(context.Background(), tt.destination)
+
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidInput)
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDestinationPolicyCheckLink(t *testing.T) {
+	policy, err := NewDestinationPolicy(config.Destinations{})
+	require.NoError(t, err)
+	policy.resolver = testResolver
+
+	err = policy.CheckLink(context.Background(), storage.URL{
+		URL:      "https://example.com",
+		Variants: []storage.Variant{{URL: "http://10.0.0.1/", Weight: 1}},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorContains(t, err, "http://10.0.0.1/ is on a private network")
+}
+
+func TestNewDestinationPolicyErrors(t *testing.T) {
+	_, err := NewDestinationPolicy(config.Destinations{Allow: []string{"https://example.com/"}})
+	assert.ErrorContains(t, err, `allow: invalid host "https://example.com/"`)
+
+	_, err = NewDestinationPolicy(config.Destinations{Deny: []string{"*.*.example"}})
+	assert.ErrorContains(t, err, `deny: invalid host "*.*.example"`)
+}
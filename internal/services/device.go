@@ -0,0 +1,58 @@
+package services
+
+import (
+	"url_shortener/internal/storage"
+
+	"github.com/mileusna/useragent"
+)
+
+// deviceOSes and deviceClasses are the values device rules can match.
+var (
+	deviceOSes    = []string{"ios", "android", "windows", "macos", "linux", "chromeos"}
+	deviceClasses = []string{"mobile", "tablet", "desktop", "bot"}
+)
+
+// device is what device rules know about a visitor, with empty fields for
+// what the User-Agent does not tell.
+type device struct {
+	os    string
+	class string
+}
+
+func parseDevice(userAgent string) device {
+	ua := useragent.Parse(userAgent)
+
+	var d device
+	switch ua.OS {
+	case useragent.IOS:
+		d.os = "ios"
+	case useragent.Android:
+		d.os = "android"
+	case useragent.Windows, useragent.WindowsNT:
+		d.os = "windows"
+	case useragent.MacOS:
+		d.os = "macos"
+	case useragent.Linux:
+		d.os = "linux"
+	case useragent.ChromeOS, useragent.CrOS:
+		d.os = "chromeos"
+	}
+
+	// crawlers often claim a desktop or mobile browser as well
+	switch {
+	case ua.Bot:
+		d.class = "bot"
+	case ua.Tablet:
+		d.class = "tablet"
+	case ua.Mobile:
+		d.class = "mobile"
+	case ua.Desktop:
+		d.class = "desktop"
+	}
+
+	return d
+}
+
+func (d device) matches(rule storage.DeviceRule) bool {
+	return (rule.OS == "" || rule.OS == d.os) && (rule.Device == "" || rule.Device == d.class)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevice(t *testing.T) {
+	tests := []struct {
+		name           string
+		userAgent      string
+		expectedDevice device
+	}{
+		{
+			name:           "iphone",
+			userAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			expectedDevice: device{os: "ios", class: "mobile"},
+		},
+		{
+			name:           "ipad",
+			userAgent:      "Mozilla/5.0 (iPad; CPU OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1",
+			expectedDevice: device{os: "ios", class: "tablet"},
+		},
+		{
+			name:           "android phone",
+			userAgent:      "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			expectedDevice: device{os: "android", class: "mobile"},
+		},
+		{
+			name:           "windows desktop",
+			userAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expectedDevice: device{os: "windows", class: "desktop"},
+		},
+		{
+			name:           "mac desktop",
+			userAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15",
+			expectedDevice: device{os: "macos", class: "desktop"},
+		},
+		{
+			name:           "crawler",
+			userAgent:      "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expectedDevice: device{class: "bot"},
+		},
+		{
+			name:           "missing user agent",
+			userAgent:      "",
+			expectedDevice: device{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedDevice, parseDevice(tt.userAgent))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/postgres"
+)
+
+type DomainService interface {
+	SaveDomain(ctx context.Context, name string, actor storage.Actor) (storage.Domain, error)
+	ListDomains(ctx context.Context) ([]storage.Domain, error)
+	DeleteDomain(ctx context.Context, name string, actor storage.Actor) error
+}
+
+type domainService struct {
+	domainStorage postgres.DomainStorage
+	defaultDomain string
+	log           *slog.Logger
+}
+
+var hostnameRegexp = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func NewDomainService(storage postgres.DomainStorage, cfg config.Config, logger *slog.Logger) DomainService {
+	return &domainService{domainStorage: storage, defaultDomain: normalizeHost(cfg.DefaultDomain), log: logger}
+}
+
+func (c *domainService) SaveDomain(ctx context.Context, name string, actor storage.Actor) (storage.Domain, error) {
+	const fn = "services.domain_service.SaveDomain"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	name = normalizeHost(name)
+	if !hostnameRegexp.MatchString(name) {
+		log.ErrorContext(ctx, "invalid domain name", slog.String("domain", name))
+		return storage.Domain{}, fmt.Errorf("%w: domain must be a hostname", ErrInvalidInput)
+	}
+	if name == c.defaultDomain {
+		log.ErrorContext(ctx, "default domain can not be managed", slog.String("domain", name))
+		return storage.Domain{}, fmt.Errorf("%w: %s is the default domain", ErrInvalidInput, name)
+	}
+
+	domain, err := c.domainStorage.SaveDomain(ctx, name, actor)
+	if err != nil {
+		if errors.Is(err, storage.ErrDomainExist) {
+			log.ErrorContext(ctx, "domain already exists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.Domain{}, ErrDomainAlreadyExists
+		}
+		log.ErrorContext(ctx, "server error during saving the domain", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.Domain{}, err
+	}
+
+	return domain, nil
+}
+
+func (c *domainService) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	const fn = "services.domain_service.ListDomains"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	domains, err := c.domainStorage.ListDomains(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to list domains", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+func (c *domainService) DeleteDomain(ctx context.Context, name string, actor storage.Actor) error {
+	const fn = "services.domain_service.DeleteDomain"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	if err := c.domainStorage.DeleteDomain(ctx, normalizeHost(name), actor); err != nil {
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			log.ErrorContext(ctx, "domain was not found", slog.String("domain", name))
+			return ErrDomainNotFound
+		}
+		if errors.Is(err, storage.ErrDomainInUse) {
+			log.ErrorContext(ctx, "domain still has links", slog.String("domain", name))
+			return ErrDomainInUse
+		}
+		log.ErrorContext(ctx, "error trying to delete a domain", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+
+	return nil
+}
+
+// normalizeHost lowercases a hostname and strips the port and trailing dot,
+// so "Go.Example.com.:443" becomes "go.example.com".
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSaveDomain(t *testing.T) {
+	tests := []struct {
+		name        string
+		domain      string
+		mockSetup   func(*mocks.DomainStorage)
+		expectedErr error
+	}{
+		{
+			name:   "normalized hostname",
+			domain: "Go.Brand.com.",
+			mockSetup: func(m *mocks.DomainStorage) {
+				m.On("SaveDomain", mock.Anything, "go.brand.com", testActor).Return(storage.Domain{Name: "go.brand.com"}, nil)
+			},
+		},
+		{
+			name:        "not a hostname",
+			domain:      "brand com",
+			mockSetup:   func(m *mocks.DomainStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "default domain",
+			domain:      "sho.rt",
+			mockSetup:   func(m *mocks.DomainStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:   "already exists",
+			domain: "go.brand.com",
+			mockSetup: func(m *mocks.DomainStorage) {
+				m.On("SaveDomain", mock.Anything, "go.brand.com", testActor).Return(storage.Domain{}, storage.ErrDomainExist)
+			},
+			expectedErr: ErrDomainAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.DomainStorage)
+			tt.mockSetup(mockStorage)
+
+			cfg := config.Config{HttpServer: config.HttpServer{DefaultDomain: "sho.rt"}}
+			service := NewDomainService(mockStorage, cfg, slog.Default())
+			_, err := service.SaveDomain(context.Background(), tt.domain, testActor)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteDomainInUse(t *testing.T) {
+	mockStorage := new(mocks.DomainStorage)
+	mockStorage.On("DeleteDomain", mock.Anything, "go.brand.com", testActor).Return(storage.ErrDomainInUse)
+
+	service := NewDomainService(mockStorage, config.Config{}, slog.Default())
+
+	assert.ErrorIs(t, service.DeleteDomain(context.Background(), "go.brand.com", testActor), ErrDomainInUse)
+}
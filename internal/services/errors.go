@@ -3,7 +3,17 @@ package services
 import "errors"
 
 var (
-	ErrURLAlreadyExists = errors.New("alias already exists")
-	ErrInvalidInput     = errors.New("invalid input")
-	ErrURLNotFound      = errors.New("invalid input")
+	ErrURLAlreadyExists      = errors.New("alias already exists")
+	ErrInvalidInput          = errors.New("invalid input")
+	ErrURLNotFound           = errors.New("invalid input")
+	ErrURLGone               = errors.New("url is no longer available")
+	ErrURLNeedsPreview       = errors.New("url must be previewed before redirecting")
+	ErrURLBlocked            = errors.New("url has been blocked")
+	ErrReputationUnavailable = errors.New("url reputation cannot be checked")
+
+	ErrDomainAlreadyExists = errors.New("domain already exists")
+	ErrDomainNotFound      = errors.New("domain not found")
+	ErrDomainInUse         = errors.New("domain still has links")
+
+	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
@@ -0,0 +1,106 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url_shortener/internal/storage"
+)
+
+// DomainService is an autogenerated mock type for the DomainService type
+type DomainService struct {
+	mock.Mock
+}
+
+// DeleteDomain provides a mock function with given fields: ctx, name, actor
+func (_m *DomainService) DeleteDomain(ctx context.Context, name string, actor storage.Actor) error {
+	ret := _m.Called(ctx, name, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDomain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Actor) error); ok {
+		r0 = rf(ctx, name, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListDomains provides a mock function with given fields: ctx
+func (_m *DomainService) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDomains")
+	}
+
+	var r0 []storage.Domain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Domain, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Domain); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Domain)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveDomain provides a mock function with given fields: ctx, name, actor
+func (_m *DomainService) SaveDomain(ctx context.Context, name string, actor storage.Actor) (storage.Domain, error) {
+	ret := _m.Called(ctx, name, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDomain")
+	}
+
+	var r0 storage.Domain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Actor) (storage.Domain, error)); ok {
+		return rf(ctx, name, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Actor) storage.Domain); ok {
+		r0 = rf(ctx, name, actor)
+	} else {
+		r0 = ret.Get(0).(storage.Domain)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, storage.Actor) error); ok {
+		r1 = rf(ctx, name, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewDomainService creates a new instance of DomainService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDomainService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DomainService {
+	mock := &DomainService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -2,24 +2,61 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+	services "url_shortener/internal/services"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url_shortener/internal/storage"
+
+	time "time"
+)
 
 // UrlService is an autogenerated mock type for the UrlService type
 type UrlService struct {
 	mock.Mock
 }
 
-// DeleteURL provides a mock function with given fields: alias
-func (_m *UrlService) DeleteURL(alias string) error {
-	ret := _m.Called(alias)
+// BlockURL provides a mock function with given fields: ctx, domain, alias, reason, actor
+func (_m *UrlService) BlockURL(ctx context.Context, domain string, alias string, reason string, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, reason, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BlockURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, reason, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, reason, actor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, storage.Actor) error); ok {
+		r1 = rf(ctx, domain, alias, reason, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteURL provides a mock function with given fields: ctx, domain, alias, actor
+func (_m *UrlService) DeleteURL(ctx context.Context, domain string, alias string, actor storage.Actor) error {
+	ret := _m.Called(ctx, domain, alias, actor)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteURL")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Actor) error); ok {
+		r0 = rf(ctx, domain, alias, actor)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -27,27 +64,169 @@ func (_m *UrlService) DeleteURL(alias string) error {
 	return r0
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *UrlService) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// GetURL provides a mock function with given fields: ctx, domain, alias, confirmed, visitor
+func (_m *UrlService) GetURL(ctx context.Context, domain string, alias string, confirmed bool, visitor services.Visitor) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, confirmed, visitor)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetURL")
 	}
 
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, services.Visitor) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, confirmed, visitor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, services.Visitor) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, confirmed, visitor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool, services.Visitor) error); ok {
+		r1 = rf(ctx, domain, alias, confirmed, visitor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURLInfo provides a mock function with given fields: ctx, domain, alias
+func (_m *UrlService) GetURLInfo(ctx context.Context, domain string, alias string) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURLInfo")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.URL); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkStats provides a mock function with given fields: ctx, domain, alias
+func (_m *UrlService) LinkStats(ctx context.Context, domain string, alias string) (storage.LinkStats, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkStats")
+	}
+
+	var r0 storage.LinkStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.LinkStats, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.LinkStats); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.LinkStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListURLs provides a mock function with given fields: ctx, filter
+func (_m *UrlService) ListURLs(ctx context.Context, filter storage.URLFilter) ([]storage.URL, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListURLs")
+	}
+
+	var r0 []storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URLFilter) ([]storage.URL, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URLFilter) []storage.URL); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URLFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MatchRule provides a mock function with given fields: ctx, domain, alias, visitor, at
+func (_m *UrlService) MatchRule(ctx context.Context, domain string, alias string, visitor services.Visitor, at time.Time) (services.RuleMatch, error) {
+	ret := _m.Called(ctx, domain, alias, visitor, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MatchRule")
+	}
+
+	var r0 services.RuleMatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, services.Visitor, time.Time) (services.RuleMatch, error)); ok {
+		return rf(ctx, domain, alias, visitor, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, services.Visitor, time.Time) services.RuleMatch); ok {
+		r0 = rf(ctx, domain, alias, visitor, at)
+	} else {
+		r0 = ret.Get(0).(services.RuleMatch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, services.Visitor, time.Time) error); ok {
+		r1 = rf(ctx, domain, alias, visitor, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveDomain provides a mock function with given fields: ctx, host
+func (_m *UrlService) ResolveDomain(ctx context.Context, host string) (string, error) {
+	ret := _m.Called(ctx, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveDomain")
+	}
+
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, host)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, host)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, host)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,22 +234,118 @@ func (_m *UrlService) GetURL(alias string) (string, error) {
 	return r0, r1
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *UrlService) SaveURL(urlToSave string, alias string) error {
-	ret := _m.Called(urlToSave, alias)
+// SaveURL provides a mock function with given fields: ctx, link, reuseExisting, actor
+func (_m *UrlService) SaveURL(ctx context.Context, link storage.URL, reuseExisting bool, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, link, reuseExisting, actor)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveURL")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(urlToSave, alias)
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, bool, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, link, reuseExisting, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, bool, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, link, reuseExisting, actor)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(storage.URL)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URL, bool, storage.Actor) error); ok {
+		r1 = rf(ctx, link, reuseExisting, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TagStats provides a mock function with given fields: ctx
+func (_m *UrlService) TagStats(ctx context.Context) ([]storage.TagStats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TagStats")
+	}
+
+	var r0 []storage.TagStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.TagStats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.TagStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.TagStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnblockURL provides a mock function with given fields: ctx, domain, alias, actor
+func (_m *UrlService) UnblockURL(ctx context.Context, domain string, alias string, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnblockURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, actor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.Actor) error); ok {
+		r1 = rf(ctx, domain, alias, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateURL provides a mock function with given fields: ctx, link, actor
+func (_m *UrlService) UpdateURL(ctx context.Context, link storage.URL, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, link, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, link, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, link, actor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URL, storage.Actor) error); ok {
+		r1 = rf(ctx, link, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewUrlService creates a new instance of UrlService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -0,0 +1,136 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url_shortener/internal/storage"
+)
+
+// WebhookService is an autogenerated mock type for the WebhookService type
+type WebhookService struct {
+	mock.Mock
+}
+
+// DeleteSubscription provides a mock function with given fields: ctx, id, actor
+func (_m *WebhookService) DeleteSubscription(ctx context.Context, id int64, actor storage.Actor) error {
+	ret := _m.Called(ctx, id, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, storage.Actor) error); ok {
+		r0 = rf(ctx, id, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListDeliveries provides a mock function with given fields: ctx, filter
+func (_m *WebhookService) ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]storage.Delivery, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeliveries")
+	}
+
+	var r0 []storage.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.DeliveryFilter) ([]storage.Delivery, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.DeliveryFilter) []storage.Delivery); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.DeliveryFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSubscriptions provides a mock function with given fields: ctx
+func (_m *WebhookService) ListSubscriptions(ctx context.Context) ([]storage.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSubscriptions")
+	}
+
+	var r0 []storage.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveSubscription provides a mock function with given fields: ctx, sub, actor
+func (_m *WebhookService) SaveSubscription(ctx context.Context, sub storage.Subscription, actor storage.Actor) (storage.Subscription, error) {
+	ret := _m.Called(ctx, sub, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSubscription")
+	}
+
+	var r0 storage.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Subscription, storage.Actor) (storage.Subscription, error)); ok {
+		return rf(ctx, sub, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Subscription, storage.Actor) storage.Subscription); ok {
+		r0 = rf(ctx, sub, actor)
+	} else {
+		r0 = ret.Get(0).(storage.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Subscription, storage.Actor) error); ok {
+		r1 = rf(ctx, sub, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWebhookService creates a new instance of WebhookService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookService {
+	mock := &WebhookService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
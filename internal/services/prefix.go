@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"url_shortener/internal/storage"
+)
+
+// resolvePath splits the path of a visit into the alias of the link serving
+// it and the suffix a prefix link forwards, empty for the link itself. The
+// suffix keeps the case of the visited path when aliases are folded.
+func (c *urlService) resolvePath(ctx context.Context, domain string, path string) (string, string, error) {
+	key := c.aliases.Fold(path)
+	if !strings.Contains(key, "/") {
+		return key, "", nil
+	}
+
+	alias, err := c.urlStorage.ResolvePath(ctx, domain, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	var suffix string
+	segments := strings.Count(alias, "/") + 1
+	if parts := strings.SplitN(path, "/", segments+1); len(parts) > segments {
+		suffix = "/" + parts[segments]
+	}
+	// the suffix must not climb out of the path of the destination
+	if slices.ContainsFunc(strings.Split(suffix, "/"), func(segment string) bool { return segment == "." || segment == ".." }) {
+		return "", "", storage.ErrURLNotFound
+	}
+
+	return alias, suffix, nil
+}
+
+// withPath appends the suffix forwarded by a prefix link to the path of the
+// destination, keeping its query and fragment.
+func withPath(destination string, suffix string) (string, error) {
+	if suffix == "" {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	return u.JoinPath(suffix).String(), nil
+}
+
+// validAliasPath reports whether the segments of an alias holding slashes
+// are all set, "docs/v2" is valid but "docs/" and "docs//v2" are not.
+func validAliasPath(alias string) bool {
+	return !slices.ContainsFunc(strings.Split(alias, "/"), func(segment string) bool {
+		return segment == "" || segment == "." || segment == ".."
+	})
+}
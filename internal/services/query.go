@@ -0,0 +1,89 @@
+package services
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"url_shortener/internal/storage"
+)
+
+// utmParameters are the keys the UTM parameters of a link can set.
+var utmParameters = []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "utm_id"}
+
+// decorate applies the query policies of the link to its destination: the
+// query of the short link is forwarded first, then the UTM parameters fill
+// in the keys still missing.
+func decorate(link storage.URL, query url.Values) (string, error) {
+	target := link.URL
+
+	if link.QueryPassthrough != storage.QueryDrop && len(query) > 0 {
+		var err error
+		target, err = withQuery(target, query, link.QueryPassthrough == storage.QueryOverride)
+		if err != nil {
+			return link.URL, err
+		}
+	}
+
+	if len(link.UTM) > 0 {
+		utm := url.Values{}
+		for key, value := range link.UTM {
+			utm.Set(key, value)
+		}
+
+		var err error
+		target, err = withQuery(target, utm, false)
+		if err != nil {
+			return link.URL, err
+		}
+	}
+
+	return target, nil
+}
+
+// withQuery adds params to the query of destination. Keys the destination
+// already has are kept unless override is set, in which case all of their
+// values are replaced. The rest of the destination, the fragment included,
+// and the encoding of the parameters it keeps are left untouched.
+func withQuery(destination string, params url.Values, override bool) (string, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	var pairs []string
+	present := map[string]bool{}
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if override && params.Has(key) {
+			continue
+		}
+		present[key] = true
+		pairs = append(pairs, pair)
+	}
+
+	added := false
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		if present[key] {
+			continue
+		}
+		for _, value := range params[key] {
+			pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			added = true
+		}
+	}
+	if !added {
+		return destination, nil
+	}
+
+	u.RawQuery = strings.Join(pairs, "&")
+	u.ForceQuery = false
+	return u.String(), nil
+}
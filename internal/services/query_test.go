@@ -0,0 +1,86 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"url_shortener/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecorate(t *testing.T) {
+	tests := []struct {
+		name        string
+		link        storage.URL
+		query       url.Values
+		expectedURL string
+	}{
+		{
+			name:        "query is dropped by default",
+			link:        storage.URL{URL: "https://example.com/page"},
+			query:       url.Values{"utm_source": {"x"}},
+			expectedURL: "https://example.com/page",
+		},
+		{
+			name:        "merge adds missing parameters",
+			link:        storage.URL{URL: "https://example.com/page?ref=site", QueryPassthrough: storage.QueryMerge},
+			query:       url.Values{"utm_source": {"x"}, "ref": {"mail"}},
+			expectedURL: "https://example.com/page?ref=site&utm_source=x",
+		},
+		{
+			name:        "override replaces all values of a parameter",
+			link:        storage.URL{URL: "https://example.com/page?tag=a&ref=site&tag=b", QueryPassthrough: storage.QueryOverride},
+			query:       url.Values{"tag": {"c"}},
+			expectedURL: "https://example.com/page?ref=site&tag=c",
+		},
+		{
+			name:        "repeated parameters are forwarded",
+			link:        storage.URL{URL: "https://example.com/", QueryPassthrough: storage.QueryMerge},
+			query:       url.Values{"id": {"1", "2"}},
+			expectedURL: "https://example.com/?id=1&id=2",
+		},
+		{
+			name:        "fragment stays last",
+			link:        storage.URL{URL: "https://example.com/docs?v=2#install", QueryPassthrough: storage.QueryMerge},
+			query:       url.Values{"utm_source": {"x"}},
+			expectedURL: "https://example.com/docs?v=2&utm_source=x#install",
+		},
+		{
+			name:        "fragment without query",
+			link:        storage.URL{URL: "https://example.com/app#/settings?tab=1", UTM: map[string]string{"utm_source": "news"}},
+			expectedURL: "https://example.com/app?utm_source=news#/settings?tab=1",
+		},
+		{
+			name:        "values are encoded",
+			link:        storage.URL{URL: "https://example.com/search", QueryPassthrough: storage.QueryMerge},
+			query:       url.Values{"q": {"a&b=c d#e"}},
+			expectedURL: "https://example.com/search?q=a%26b%3Dc+d%23e",
+		},
+		{
+			name:        "encoding of the destination is kept",
+			link:        storage.URL{URL: "https://example.com/search?q=a%20b&x", UTM: map[string]string{"utm_campaign": "spring sale"}},
+			expectedURL: "https://example.com/search?q=a%20b&x&utm_campaign=spring+sale",
+		},
+		{
+			name:        "utm fills in what the query did not set",
+			link:        storage.URL{URL: "https://example.com/", QueryPassthrough: storage.QueryMerge, UTM: map[string]string{"utm_source": "news", "utm_medium": "email"}},
+			query:       url.Values{"utm_source": {"partner"}},
+			expectedURL: "https://example.com/?utm_source=partner&utm_medium=email",
+		},
+		{
+			name:        "utm does not replace the destination parameters",
+			link:        storage.URL{URL: "https://example.com/?utm_source=site", UTM: map[string]string{"utm_source": "news"}},
+			expectedURL: "https://example.com/?utm_source=site",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decorate(tt.link, tt.query)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, got)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"url_shortener/internal/storage"
+
+	// rules name their timezone, so the zones must not depend on the host
+	_ "time/tzdata"
+)
+
+// weekdays are the values of the days of a rule, indexed by time.Weekday.
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// RuleMatch reports the rule of a link a visit matches.
+type RuleMatch struct {
+	Matched bool
+	Index   int // position of the rule in the rules of the link
+	Rule    storage.RedirectRule
+}
+
+// visit is what rules know about a request.
+type visit struct {
+	at       time.Time
+	language string
+	query    url.Values
+}
+
+func newVisit(visitor Visitor, at time.Time) visit {
+	return visit{at: at, language: preferredLanguage(visitor.AcceptLanguage), query: visitor.Query}
+}
+
+// matchRule returns the first of the rules the visit matches.
+func matchRule(rules []storage.RedirectRule, v visit) RuleMatch {
+	for i, rule := range rules {
+		if v.matches(rule) {
+			return RuleMatch{Matched: true, Index: i, Rule: rule}
+		}
+	}
+	return RuleMatch{}
+}
+
+func (v visit) matches(rule storage.RedirectRule) bool {
+	if rule.After != nil && v.at.Before(*rule.After) {
+		return false
+	}
+	if rule.Before != nil && !v.at.Before(*rule.Before) {
+		return false
+	}
+
+	local := v.at.In(location(rule.Timezone))
+	if len(rule.Days) > 0 && !slices.Contains(rule.Days, weekdays[local.Weekday()]) {
+		return false
+	}
+	if rule.From != "" {
+		from, to, now := clockMinutes(rule.From), clockMinutes(rule.To), local.Hour()*60+local.Minute()
+		if from < to && (now < from || now >= to) {
+			return false
+		}
+		if from > to && now < from && now >= to {
+			return false
+		}
+	}
+
+	if len(rule.Languages) > 0 && !slices.ContainsFunc(rule.Languages, func(language string) bool {
+		return v.language == language || strings.HasPrefix(v.language, language+"-")
+	}) {
+		return false
+	}
+
+	for key, value := range rule.Query {
+		if !v.query.Has(key) || (value != "" && v.query.Get(key) != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// preferredLanguage returns the lowercased language of an Accept-Language
+// header with the highest quality, the first one of those tied.
+func preferredLanguage(header string) string {
+	var preferred string
+	best := 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > best {
+			preferred, best = tag, quality
+		}
+	}
+	return preferred
+}
+
+// parseClock parses a time of day as "15:04".
+func parseClock(clock string) (time.Time, error) {
+	return time.Parse("15:04", clock)
+}
+
+// clockMinutes returns the minutes since midnight of a validated "15:04".
+func clockMinutes(clock string) int {
+	t, _ := parseClock(clock)
+	return t.Hour()*60 + t.Minute()
+}
+
+// locations caches the zones of the rules, loading one parses the tzdata.
+var locations sync.Map
+
+// location returns the validated zone, UTC for an empty name.
+func location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
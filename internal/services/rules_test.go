@@ -0,0 +1,76 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+	"url_shortener/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: ""},
+		{header: "de-AT", expected: "de-at"},
+		{header: "fr;q=0.8, de-AT, de;q=0.9", expected: "de-at"},
+		{header: "en;q=0.5, fr;q=0.5", expected: "en"},
+		{header: "*, es;q=0.2", expected: "es"},
+		{header: "it;q=abc, pt-BR;q=0.1", expected: "pt-br"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, preferredLanguage(tt.header))
+		})
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	launch := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	rules := []storage.RedirectRule{
+		{Query: map[string]string{"ref": "partner"}, URL: "https://example.com/partner"},
+		{Query: map[string]string{"debug": ""}, URL: "https://example.com/debug"},
+		{Before: &launch, URL: "https://example.com/teaser"},
+		{Days: []string{"sat", "sun"}, Timezone: "Europe/Berlin", URL: "https://example.com/weekend"},
+		{From: "22:00", To: "06:00", Timezone: "Europe/Berlin", URL: "https://example.com/night"},
+		{Languages: []string{"de"}, URL: "https://example.de"},
+	}
+
+	// 2025-03-05 is a Wednesday, Berlin is at UTC+1
+	wednesday := func(hour int) time.Time { return time.Date(2025, 3, 5, hour, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name          string
+		visit         visit
+		expectedMatch bool
+		expectedIndex int
+	}{
+		{name: "query value", visit: visit{at: wednesday(12), query: url.Values{"ref": {"partner"}}}, expectedMatch: true, expectedIndex: 0},
+		{name: "other query value", visit: visit{at: wednesday(12), query: url.Values{"ref": {"ads"}}}, expectedMatch: false},
+		{name: "query presence", visit: visit{at: wednesday(12), query: url.Values{"debug": {"1"}}}, expectedMatch: true, expectedIndex: 1},
+		{name: "before launch", visit: visit{at: launch.Add(-time.Second)}, expectedMatch: true, expectedIndex: 2},
+		{name: "weekend in the zone of the rule", visit: visit{at: time.Date(2025, 3, 7, 23, 30, 0, 0, time.UTC)}, expectedMatch: true, expectedIndex: 3},
+		{name: "window after midnight", visit: visit{at: wednesday(3)}, expectedMatch: true, expectedIndex: 4},
+		{name: "window before midnight", visit: visit{at: wednesday(21)}, expectedMatch: true, expectedIndex: 4},
+		{name: "window end is excluded", visit: visit{at: wednesday(5)}, expectedMatch: false},
+		{name: "language region", visit: visit{at: wednesday(12), language: "de-at"}, expectedMatch: true, expectedIndex: 5},
+		{name: "language prefix only", visit: visit{at: wednesday(12), language: "dee"}, expectedMatch: false},
+		{name: "nothing matches", visit: visit{at: wednesday(12), language: "en"}, expectedMatch: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := matchRule(rules, tt.visit)
+
+			assert.Equal(t, tt.expectedMatch, match.Matched)
+			if tt.expectedMatch {
+				assert.Equal(t, tt.expectedIndex, match.Index)
+				assert.Equal(t, rules[tt.expectedIndex].URL, match.Rule.URL)
+			}
+		})
+	}
+}
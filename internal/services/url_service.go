@@ -1,74 +1,952 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/geoip"
+	"url_shortener/internal/reputation"
 	"url_shortener/internal/storage"
 	"url_shortener/internal/storage/postgres"
+	"url_shortener/internal/tracing"
+
+	"go.opentelemetry.io/otel"
 )
 
 type UrlService interface {
-	SaveURL(urlToSave string, alias string) error
-	GetURL(alias string) (string, error)
-	DeleteURL(alias string) error
+	SaveURL(ctx context.Context, link storage.URL, reuseExisting bool, actor storage.Actor) (storage.URL, error)
+	ResolveDomain(ctx context.Context, host string) (string, error)
+	GetURL(ctx context.Context, domain string, alias string, confirmed bool, visitor Visitor) (storage.URL, error)
+	GetURLInfo(ctx context.Context, domain string, alias string) (storage.URL, error)
+	ListURLs(ctx context.Context, filter storage.URLFilter) ([]storage.URL, error)
+	UpdateURL(ctx context.Context, link storage.URL, actor storage.Actor) (storage.URL, error)
+	DeleteURL(ctx context.Context, domain string, alias string, actor storage.Actor) error
+	BlockURL(ctx context.Context, domain string, alias string, reason string, actor storage.Actor) (storage.URL, error)
+	UnblockURL(ctx context.Context, domain string, alias string, actor storage.Actor) (storage.URL, error)
+	TagStats(ctx context.Context) ([]storage.TagStats, error)
+	LinkStats(ctx context.Context, domain string, alias string) (storage.LinkStats, error)
+	MatchRule(ctx context.Context, domain string, alias string, visitor Visitor, at time.Time) (RuleMatch, error)
+}
+
+// Visitor describes the client following a short link, to pick the
+// destination of the links that target visitors.
+type Visitor struct {
+	IP        string
+	UserAgent string
+	// AcceptLanguage is the Accept-Language header of the request.
+	AcceptLanguage string
+	// Query is the query of the short link.
+	Query url.Values
+	// Variant is the variant of the link the visitor was served before.
+	Variant string
 }
 
+const (
+	defaultListLimit = 50
+	maxListLimit     = 1000
+	maxTagLength     = 64
+	maxVariants      = 20
+	maxVariantWeight = 1000
+	maxRules         = 50
+	maxReasonLength  = 500
+)
+
+// tracerName names the tracer of the service spans.
+const tracerName = "url_shortener/internal/services"
+
 type urlService struct {
-	urlStorage postgres.URLStorage
-	log        *slog.Logger
+	urlStorage    postgres.URLStorage
+	geo           geoip.Resolver
+	aliases       *AliasPolicy
+	destinations  *DestinationPolicy
+	checker       reputation.Checker
+	defaultDomain string
+	log           *slog.Logger
+	// randIntN draws the variants, it returns a number in [0, n).
+	randIntN func(n int) int
+	// now is the time rules are evaluated at.
+	now func() time.Time
 }
 
-func NewURLService(storage postgres.URLStorage, logger *slog.Logger) UrlService {
-	return &urlService{urlStorage: storage, log: logger}
+// NewURLService creates the service. geo locates visitors for the country
+// overrides of links, with a nil geo links always go to their default URL.
+// aliases checks the custom aliases and destinations the destinations of
+// links, nil policies apply the defaults. checker refuses the destinations
+// known to be malicious, with a nil checker none is.
+func NewURLService(storage postgres.URLStorage, geo geoip.Resolver, aliases *AliasPolicy, destinations *DestinationPolicy, checker reputation.Checker, cfg config.Config, logger *slog.Logger) UrlService {
+	if aliases == nil {
+		aliases = defaultAliasPolicy()
+	}
+	if destinations == nil {
+		destinations = &DestinationPolicy{}
+	}
+	return &urlService{urlStorage: storage, geo: geo, aliases: aliases, destinations: destinations, checker: checker, defaultDomain: normalizeHost(cfg.DefaultDomain), log: logger, randIntN: rand.IntN, now: time.Now}
 }
 
-func (c *urlService) SaveURL(urlToSave string, alias string) error {
+// SaveURL creates the link, with a generated alias when it has none. With
+// reuseExisting, a link without an alias is not created when the owner
+// already has a link with a generated alias and default settings for the same
+// destination, which is returned with Reused set instead. Links without an
+// alias can only reuse others when they have default settings too.
+func (c *urlService) SaveURL(ctx context.Context, link storage.URL, reuseExisting bool, actor storage.Actor) (_ storage.URL, err error) {
 	const fn = "services.url_service.SaveURL"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
 	log := c.log.With(
 		slog.String("fn", fn),
 	)
 
-	if err := c.urlStorage.SaveURL(urlToSave, alias); err != nil {
+	if link.Alias != "" {
+		link.Alias, err = c.aliases.Check(link.Alias)
+		if err != nil {
+			log.ErrorContext(ctx, "alias violates the alias policy", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, err
+		}
+	}
+
+	link, err = validateLink(link)
+	if err != nil {
+		log.ErrorContext(ctx, "invalid link parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+	if err := c.destinations.CheckLink(ctx, link); err != nil {
+		log.ErrorContext(ctx, "destination is refused", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+	if err := c.checkReputation(ctx, log, link); err != nil {
+		return storage.URL{}, err
+	}
+	link.Domain = c.domainName(link.Domain)
+
+	generated := link.Alias == ""
+	if generated && reuseExisting && !defaultSettings(link) {
+		log.ErrorContext(ctx, "reuse requested for a link with settings")
+		return storage.URL{}, fmt.Errorf("%w: reuseExisting only applies to links without settings", ErrInvalidInput)
+	}
+	if generated && defaultSettings(link) {
+		link.DestinationHash = destinationHash(link.URL)
+	}
+
+	var saved storage.URL
+	for attempt := 1; ; attempt++ {
+		if generated {
+			link.Alias, err = c.generateAlias()
+			if err != nil {
+				log.ErrorContext(ctx, "failed to generate an alias", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+				return storage.URL{}, err
+			}
+		}
+		saved, err = c.urlStorage.SaveURL(ctx, link, generated && reuseExisting, actor)
+		if !generated || !errors.Is(err, storage.ErrURLExist) || attempt == maxAliasAttempts {
+			break
+		}
+		log.DebugContext(ctx, "generated alias is taken", slog.String("alias", link.Alias))
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			log.ErrorContext(ctx, "domain is not managed", slog.String("domain", link.Domain))
+			return storage.URL{}, fmt.Errorf("%w: unknown domain %s", ErrInvalidInput, link.Domain)
+		}
 		if errors.Is(err, storage.ErrURLExist) {
-			log.Error("data already exists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-			return ErrURLAlreadyExists
+			log.ErrorContext(ctx, "data already exists", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, ErrURLAlreadyExists
 		}
-		log.Error("server error during saving the URL", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-		return err
+		log.ErrorContext(ctx, "server error during saving the URL", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+	if saved.Reused {
+		log.InfoContext(ctx, "reusing the link of the destination", slog.String("alias", saved.Alias))
 	}
 
-	return nil
+	return saved, nil
+}
+
+// ResolveDomain maps the Host header of a redirect to the domain its links are
+// stored under. Hosts that are not managed fall back to the default domain.
+func (c *urlService) ResolveDomain(ctx context.Context, host string) (_ string, err error) {
+	const fn = "services.url_service.ResolveDomain"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	domain := c.domainName(host)
+	if domain == "" {
+		return "", nil
+	}
+
+	exists, err := c.urlStorage.DomainExists(ctx, domain)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to check a domain", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+
+	return domain, nil
 }
 
-func (c *urlService) GetURL(alias string) (string, error) {
+// GetURL resolves the alias for the visitor, with URL replaced by the
+// destination targeting the visitor or by the variant drawn for the visit,
+// and decorated with the path and query parameters the link forwards or
+// adds. The alias can be followed by a path below a prefix link.
+func (c *urlService) GetURL(ctx context.Context, domain string, alias string, confirmed bool, visitor Visitor) (_ storage.URL, err error) {
 	const fn = "services.url_service.GetURL"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
 	log := c.log.With(
 		slog.String("fn", fn),
 	)
 
-	url, err := c.urlStorage.GetURL(alias)
+	domain = c.domainName(domain)
+	alias, suffix, err := c.resolvePath(ctx, domain, alias)
+
+	var link storage.URL
+	if err == nil {
+		link, err = c.urlStorage.GetURL(ctx, domain, alias, confirmed)
+	}
 	if err != nil {
 		if errors.Is(err, storage.ErrURLNotFound) {
-			log.Error("url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-			return "", ErrURLNotFound
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, ErrURLNotFound
 		}
-		log.Error("error trying to get a url", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
-		return "", err
+		if errors.Is(err, storage.ErrURLExhausted) {
+			log.InfoContext(ctx, "url with provided alias has reached its visits limit", slog.String("alias", alias))
+			return storage.URL{}, ErrURLGone
+		}
+		if errors.Is(err, storage.ErrURLExpired) {
+			log.InfoContext(ctx, "url with provided alias has expired", slog.String("alias", alias))
+			return storage.URL{}, ErrURLGone
+		}
+		if errors.Is(err, storage.ErrURLNeedsConfirmation) {
+			log.DebugContext(ctx, "url with provided alias requires an interstitial", slog.String("alias", alias))
+			return storage.URL{}, ErrURLNeedsPreview
+		}
+		if errors.Is(err, storage.ErrURLBlocked) {
+			log.InfoContext(ctx, "url with provided alias is blocked", slog.String("alias", alias))
+			return storage.URL{}, ErrURLBlocked
+		}
+		log.ErrorContext(ctx, "error trying to get a url", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
 	}
 
-	return url, nil
+	link.URL, link.Variant = c.destination(ctx, log, link, visitor)
+	link.URL, err = withPath(link.URL, suffix)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to forward the path", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+	link.URL, err = decorate(link, visitor.Query)
+	if err != nil {
+		// the destination still works without the parameters
+		log.WarnContext(ctx, "failed to add the query parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+	if link.Variant != "" {
+		// the redirect is served even if the click cannot be counted
+		if err := c.urlStorage.CountVariantClick(ctx, link.ID, link.Variant); err != nil {
+			log.WarnContext(ctx, "failed to count the variant click", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+	}
+
+	return link, nil
 }
 
-func (c *urlService) DeleteURL(alias string) error {
+// destination picks the URL the visitor is sent to: the first matching rule,
+// then the first matching device rule, then the override of the country of
+// the visitor, then a variant, and the default URL of the link when none
+// applies. The name of the variant is returned when one was served.
+func (c *urlService) destination(ctx context.Context, log *slog.Logger, link storage.URL, visitor Visitor) (string, string) {
+	if len(link.Rules) > 0 {
+		if match := matchRule(link.Rules, newVisit(visitor, c.now())); match.Matched {
+			return match.Rule.URL, ""
+		}
+	}
+
+	if len(link.DeviceRules) > 0 {
+		device := parseDevice(visitor.UserAgent)
+		for _, rule := range link.DeviceRules {
+			if device.matches(rule) {
+				return rule.URL, ""
+			}
+		}
+	}
+
+	if target, ok := c.countryTarget(ctx, log, link, visitor); ok {
+		return target, ""
+	}
+
+	if len(link.Variants) > 0 {
+		variant := c.pickVariant(link, visitor)
+		return variant.URL, variant.Name
+	}
+
+	return link.URL, ""
+}
+
+// countryTarget returns the override for the country of the visitor.
+func (c *urlService) countryTarget(ctx context.Context, log *slog.Logger, link storage.URL, visitor Visitor) (string, bool) {
+	if len(link.GeoTargets) == 0 || c.geo == nil {
+		return "", false
+	}
+
+	country, err := c.geo.Country(visitor.IP)
+	if err != nil {
+		log.WarnContext(ctx, "failed to locate the visitor", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return "", false
+	}
+
+	target, ok := link.GeoTargets[country]
+	return target, ok
+}
+
+// pickVariant keeps the variant a returning visitor was served on sticky
+// links and otherwise draws one by weight.
+func (c *urlService) pickVariant(link storage.URL, visitor Visitor) storage.Variant {
+	if link.StickyVariants && visitor.Variant != "" {
+		for _, variant := range link.Variants {
+			if variant.Name == visitor.Variant {
+				return variant
+			}
+		}
+	}
+
+	total := 0
+	for _, variant := range link.Variants {
+		total += variant.Weight
+	}
+
+	n := c.randIntN(total)
+	for _, variant := range link.Variants {
+		if n < variant.Weight {
+			return variant
+		}
+		n -= variant.Weight
+	}
+	return link.Variants[len(link.Variants)-1]
+}
+
+// GetURLInfo returns the link without visiting it. Like GetURL, a path below
+// a prefix link resolves to the prefix link.
+func (c *urlService) GetURLInfo(ctx context.Context, domain string, alias string) (_ storage.URL, err error) {
+	const fn = "services.url_service.GetURLInfo"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	domain = c.domainName(domain)
+	alias, _, err = c.resolvePath(ctx, domain, alias)
+
+	var link storage.URL
+	if err == nil {
+		link, err = c.urlStorage.GetURLInfo(ctx, domain, alias)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, ErrURLNotFound
+		}
+		log.ErrorContext(ctx, "error trying to get url info", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+
+	return link, nil
+}
+
+func (c *urlService) ListURLs(ctx context.Context, filter storage.URLFilter) (_ []storage.URL, err error) {
+	const fn = "services.url_service.ListURLs"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	if filter.Limit < 0 || filter.Limit > maxListLimit || filter.Offset < 0 {
+		log.ErrorContext(ctx, "invalid pagination", slog.Int("limit", filter.Limit), slog.Int("offset", filter.Offset))
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d and offset must not be negative", ErrInvalidInput, maxListLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	filter.Folder = strings.Trim(filter.Folder, "/")
+	if !filter.AllDomains {
+		filter.Domain = c.domainName(filter.Domain)
+	}
+
+	links, err := c.urlStorage.ListURLs(ctx, filter)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to list urls", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (c *urlService) UpdateURL(ctx context.Context, link storage.URL, actor storage.Actor) (_ storage.URL, err error) {
+	const fn = "services.url_service.UpdateURL"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	link, err = validateLink(link)
+	if err != nil {
+		log.ErrorContext(ctx, "invalid link parameters", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+	if err := c.destinations.CheckLink(ctx, link); err != nil {
+		log.ErrorContext(ctx, "destination is refused", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+	if err := c.checkReputation(ctx, log, link); err != nil {
+		return storage.URL{}, err
+	}
+	link.Domain = c.domainName(link.Domain)
+	link.Alias = c.aliases.Fold(link.Alias)
+	// the storage only keeps it for links with a generated alias, links given
+	// settings are no longer reused
+	if defaultSettings(link) {
+		link.DestinationHash = destinationHash(link.URL)
+	}
+
+	updated, err := c.urlStorage.UpdateURL(ctx, link, actor)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, ErrURLNotFound
+		}
+		log.ErrorContext(ctx, "error trying to update a url", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+
+	return updated, nil
+}
+
+func (c *urlService) DeleteURL(ctx context.Context, domain string, alias string, actor storage.Actor) (err error) {
 	const fn = "services.url_service.DeleteURL"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
 	log := c.log.With(
 		slog.String("fn", fn),
 	)
 
-	if err := c.urlStorage.DeleteURL(alias); err != nil {
-		log.Error("error trying to delete an alias", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	if err := c.urlStorage.DeleteURL(ctx, c.domainName(domain), c.aliases.Fold(alias), actor); err != nil {
+		log.ErrorContext(ctx, "error trying to delete an alias", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
 		return err
 	}
 
 	return nil
 }
+
+// BlockURL makes the link serve a warning page with the reason instead of
+// redirecting, for links found to be abused after they were created.
+func (c *urlService) BlockURL(ctx context.Context, domain string, alias string, reason string, actor storage.Actor) (_ storage.URL, err error) {
+	const fn = "services.url_service.BlockURL"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxReasonLength {
+		log.ErrorContext(ctx, "block reason is too long", slog.Int("length", len([]rune(reason))))
+		return storage.URL{}, fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidInput, maxReasonLength)
+	}
+
+	link, err := c.urlStorage.BlockURL(ctx, c.domainName(domain), c.aliases.Fold(alias), reason, actor)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, ErrURLNotFound
+		}
+		log.ErrorContext(ctx, "error trying to block a url", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+
+	log.InfoContext(ctx, "url has been blocked", slog.String("alias", link.Alias), slog.String("user", actor.User))
+	return link, nil
+}
+
+// checkReputation refuses the links with a destination the checker lists as
+// malicious.
+func (c *urlService) checkReputation(ctx context.Context, log *slog.Logger, link storage.URL) error {
+	if c.checker == nil {
+		return nil
+	}
+
+	verdict, destination, err := reputation.CheckLink(ctx, c.checker, link)
+	if err != nil {
+		log.ErrorContext(ctx, "reputation of the destination cannot be checked", slog.String("destination", destination), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return fmt.Errorf("%w: %w", ErrReputationUnavailable, err)
+	}
+	if verdict.Malicious() {
+		log.WarnContext(ctx, "destination is flagged as malicious", slog.String("destination", destination), slog.String("threat", verdict.Threat), slog.String("source", verdict.Source))
+		return fmt.Errorf("%w: destination %s is flagged as %s by %s", ErrInvalidInput, destination, verdict.Threat, verdict.Source)
+	}
+
+	return nil
+}
+
+// UnblockURL makes a blocked link redirect again.
+func (c *urlService) UnblockURL(ctx context.Context, domain string, alias string, actor storage.Actor) (_ storage.URL, err error) {
+	const fn = "services.url_service.UnblockURL"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	link, err := c.urlStorage.UnblockURL(ctx, c.domainName(domain), c.aliases.Fold(alias), actor)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.URL{}, ErrURLNotFound
+		}
+		log.ErrorContext(ctx, "error trying to unblock a url", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.URL{}, err
+	}
+
+	log.InfoContext(ctx, "url has been unblocked", slog.String("alias", link.Alias), slog.String("user", actor.User))
+	return link, nil
+}
+
+func (c *urlService) TagStats(ctx context.Context) (_ []storage.TagStats, err error) {
+	const fn = "services.url_service.TagStats"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	stats, err := c.urlStorage.TagStats(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to get tag stats", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// LinkStats returns the visits of the link and of each of its variants.
+func (c *urlService) LinkStats(ctx context.Context, domain string, alias string) (_ storage.LinkStats, err error) {
+	const fn = "services.url_service.LinkStats"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	stats, err := c.urlStorage.LinkStats(ctx, c.domainName(domain), c.aliases.Fold(alias))
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.LinkStats{}, ErrURLNotFound
+		}
+		log.ErrorContext(ctx, "error trying to get link stats", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.LinkStats{}, err
+	}
+
+	return stats, nil
+}
+
+// MatchRule reports the rule of the link a visit at the given time would
+// match, without visiting the link.
+func (c *urlService) MatchRule(ctx context.Context, domain string, alias string, visitor Visitor, at time.Time) (_ RuleMatch, err error) {
+	const fn = "services.url_service.MatchRule"
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fn)
+	defer tracing.End(span, &err)
+
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	link, err := c.urlStorage.GetURLInfo(ctx, c.domainName(domain), c.aliases.Fold(alias))
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.ErrorContext(ctx, "url with provided alias was not found", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return RuleMatch{}, ErrURLNotFound
+		}
+		log.ErrorContext(ctx, "error trying to get url info", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return RuleMatch{}, err
+	}
+
+	return matchRule(link.Rules, newVisit(visitor, at)), nil
+}
+
+// domainName normalizes a hostname the way domains are stored, with the
+// default domain stored as an empty string.
+func (c *urlService) domainName(host string) string {
+	domain := normalizeHost(host)
+	if domain == c.defaultDomain {
+		return ""
+	}
+	return domain
+}
+
+// defaultSettings reports whether the validated link has nothing but a
+// destination, so it behaves like any other link for the same destination.
+func defaultSettings(link storage.URL) bool {
+	return link.MaxVisits == nil && !link.Interstitial && link.ExpiresAt == nil && link.RedirectType == http.StatusFound &&
+		len(link.Tags) == 0 && link.Folder == "" && len(link.GeoTargets) == 0 && len(link.Rules) == 0 && len(link.DeviceRules) == 0 &&
+		len(link.Variants) == 0 && !link.StickyVariants && link.QueryPassthrough == storage.QueryDrop && len(link.UTM) == 0 && !link.Prefix
+}
+
+// validateLink checks the settings shared by create and update and fills in
+// the defaults.
+func validateLink(link storage.URL) (storage.URL, error) {
+	if link.MaxVisits != nil && *link.MaxVisits <= 0 {
+		return link, fmt.Errorf("%w: maxVisits must be positive", ErrInvalidInput)
+	}
+
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return link, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidInput)
+	}
+
+	switch link.RedirectType {
+	case 0:
+		link.RedirectType = http.StatusFound
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return link, fmt.Errorf("%w: redirectType must be one of 301, 302, 307, 308", ErrInvalidInput)
+	}
+
+	tags, err := normalizeTags(link.Tags)
+	if err != nil {
+		return link, err
+	}
+	link.Tags = tags
+
+	folder, err := normalizeFolder(link.Folder)
+	if err != nil {
+		return link, err
+	}
+	link.Folder = folder
+
+	geoTargets, err := normalizeGeoTargets(link.GeoTargets)
+	if err != nil {
+		return link, err
+	}
+	link.GeoTargets = geoTargets
+
+	rules, err := normalizeRules(link.Rules)
+	if err != nil {
+		return link, err
+	}
+	link.Rules = rules
+
+	deviceRules, err := normalizeDeviceRules(link.DeviceRules)
+	if err != nil {
+		return link, err
+	}
+	link.DeviceRules = deviceRules
+
+	variants, err := normalizeVariants(link.Variants)
+	if err != nil {
+		return link, err
+	}
+	link.Variants = variants
+	if link.StickyVariants && len(link.Variants) == 0 {
+		return link, fmt.Errorf("%w: stickyVariants requires variants", ErrInvalidInput)
+	}
+
+	switch link.QueryPassthrough {
+	case storage.QueryDrop, storage.QueryMerge, storage.QueryOverride:
+	case "drop":
+		link.QueryPassthrough = storage.QueryDrop
+	default:
+		return link, fmt.Errorf("%w: queryPassthrough must be one of drop, merge, override", ErrInvalidInput)
+	}
+
+	utm, err := normalizeUTM(link.UTM)
+	if err != nil {
+		return link, err
+	}
+	link.UTM = utm
+
+	return link, nil
+}
+
+// normalizeTags lowercases and sorts the tags and drops duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: tags must be between 1 and %d characters", ErrInvalidInput, maxTagLength)
+		}
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// normalizeGeoTargets uppercases the country codes and checks that every
+// override is an absolute URL.
+func normalizeGeoTargets(targets map[string]string) (map[string]string, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	normalized := make(map[string]string, len(targets))
+	for country, target := range targets {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 || strings.Trim(country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("%w: geoTargets keys must be ISO 3166-1 alpha-2 country codes", ErrInvalidInput)
+		}
+		if _, ok := normalized[country]; ok {
+			return nil, fmt.Errorf("%w: geoTargets has country %s more than once", ErrInvalidInput, country)
+		}
+		if !isAbsoluteURL(target) {
+			return nil, fmt.Errorf("%w: geoTargets destination for %s must be an absolute URL", ErrInvalidInput, country)
+		}
+		normalized[country] = target
+	}
+
+	return normalized, nil
+}
+
+// normalizeUTM lowercases the keys of the UTM parameters and checks that
+// they are known and set.
+func normalizeUTM(utm map[string]string) (map[string]string, error) {
+	if len(utm) == 0 {
+		return nil, nil
+	}
+
+	normalized := make(map[string]string, len(utm))
+	for key, value := range utm {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !slices.Contains(utmParameters, key) {
+			return nil, fmt.Errorf("%w: utm keys must be one of %s", ErrInvalidInput, strings.Join(utmParameters, ", "))
+		}
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("%w: utm %s must not be empty", ErrInvalidInput, key)
+		}
+		normalized[key] = value
+	}
+
+	return normalized, nil
+}
+
+// normalizeRules lowercases the days and languages of the rules and checks
+// that every rule has a valid condition and an absolute URL.
+func normalizeRules(rules []storage.RedirectRule) ([]storage.RedirectRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if len(rules) > maxRules {
+		return nil, fmt.Errorf("%w: a link can have at most %d rules", ErrInvalidInput, maxRules)
+	}
+
+	normalized := make([]storage.RedirectRule, 0, len(rules))
+	for i, rule := range rules {
+		var days []string
+		for _, day := range rule.Days {
+			day = strings.ToLower(strings.TrimSpace(day))
+			if !slices.Contains(weekdays, day) {
+				return nil, fmt.Errorf("%w: rules[%d] days must be mon, tue, wed, thu, fri, sat or sun", ErrInvalidInput, i)
+			}
+			if !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+		}
+		rule.Days = days
+
+		rule.From, rule.To = strings.TrimSpace(rule.From), strings.TrimSpace(rule.To)
+		if (rule.From == "") != (rule.To == "") {
+			return nil, fmt.Errorf("%w: rules[%d] from and to must be set together", ErrInvalidInput, i)
+		}
+		if rule.From != "" {
+			_, fromErr := parseClock(rule.From)
+			_, toErr := parseClock(rule.To)
+			if fromErr != nil || toErr != nil {
+				return nil, fmt.Errorf("%w: rules[%d] from and to must be times of day as HH:MM", ErrInvalidInput, i)
+			}
+			if rule.From == rule.To {
+				return nil, fmt.Errorf("%w: rules[%d] from and to must differ", ErrInvalidInput, i)
+			}
+		}
+
+		rule.Timezone = strings.TrimSpace(rule.Timezone)
+		if rule.Timezone != "" {
+			if _, err := time.LoadLocation(rule.Timezone); err != nil {
+				return nil, fmt.Errorf("%w: rules[%d] timezone %q is unknown", ErrInvalidInput, i, rule.Timezone)
+			}
+		}
+
+		if rule.After != nil && rule.Before != nil && !rule.After.Before(*rule.Before) {
+			return nil, fmt.Errorf("%w: rules[%d] after must be earlier than before", ErrInvalidInput, i)
+		}
+
+		var languages []string
+		for _, language := range rule.Languages {
+			language = strings.ToLower(strings.TrimSpace(language))
+			if !validLanguage(language) {
+				return nil, fmt.Errorf("%w: rules[%d] languages must be language tags such as de or pt-br", ErrInvalidInput, i)
+			}
+			if !slices.Contains(languages, language) {
+				languages = append(languages, language)
+			}
+		}
+		rule.Languages = languages
+
+		for key := range rule.Query {
+			if key == "" {
+				return nil, fmt.Errorf("%w: rules[%d] query parameters must be named", ErrInvalidInput, i)
+			}
+		}
+		if len(rule.Query) == 0 {
+			rule.Query = nil
+		}
+
+		if len(rule.Days) == 0 && rule.From == "" && rule.After == nil && rule.Before == nil && len(rule.Languages) == 0 && len(rule.Query) == 0 {
+			return nil, fmt.Errorf("%w: rules[%d] must have a condition", ErrInvalidInput, i)
+		}
+		if !isAbsoluteURL(rule.URL) {
+			return nil, fmt.Errorf("%w: rules[%d] url must be an absolute URL", ErrInvalidInput, i)
+		}
+		normalized = append(normalized, rule)
+	}
+
+	return normalized, nil
+}
+
+// validLanguage accepts the language tags of Accept-Language, lowercased.
+func validLanguage(language string) bool {
+	for i, subtag := range strings.Split(language, "-") {
+		if len(subtag) == 0 || len(subtag) > 8 || (i == 0 && (len(subtag) < 2 || len(subtag) > 3)) {
+			return false
+		}
+		for _, r := range subtag {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// normalizeDeviceRules lowercases the conditions of the rules and checks that
+// every rule has a known condition and an absolute URL.
+func normalizeDeviceRules(rules []storage.DeviceRule) ([]storage.DeviceRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]storage.DeviceRule, 0, len(rules))
+	for i, rule := range rules {
+		rule.OS = strings.ToLower(strings.TrimSpace(rule.OS))
+		rule.Device = strings.ToLower(strings.TrimSpace(rule.Device))
+
+		if rule.OS == "" && rule.Device == "" {
+			return nil, fmt.Errorf("%w: deviceRules[%d] must have an os or a device", ErrInvalidInput, i)
+		}
+		if rule.OS != "" && !slices.Contains(deviceOSes, rule.OS) {
+			return nil, fmt.Errorf("%w: deviceRules[%d] os must be one of %s", ErrInvalidInput, i, strings.Join(deviceOSes, ", "))
+		}
+		if rule.Device != "" && !slices.Contains(deviceClasses, rule.Device) {
+			return nil, fmt.Errorf("%w: deviceRules[%d] device must be one of %s", ErrInvalidInput, i, strings.Join(deviceClasses, ", "))
+		}
+		if !isAbsoluteURL(rule.URL) {
+			return nil, fmt.Errorf("%w: deviceRules[%d] url must be an absolute URL", ErrInvalidInput, i)
+		}
+		normalized = append(normalized, rule)
+	}
+
+	return normalized, nil
+}
+
+// normalizeVariants lowercases the names of the variants and checks that a
+// split link has between 2 and maxVariants uniquely named variants with
+// positive weights.
+func normalizeVariants(variants []storage.Variant) ([]storage.Variant, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	if len(variants) < 2 || len(variants) > maxVariants {
+		return nil, fmt.Errorf("%w: a link must have between 2 and %d variants", ErrInvalidInput, maxVariants)
+	}
+
+	normalized := make([]storage.Variant, 0, len(variants))
+	for i, variant := range variants {
+		variant.Name = strings.ToLower(strings.TrimSpace(variant.Name))
+
+		if !validVariantName(variant.Name) {
+			return nil, fmt.Errorf("%w: variants[%d] name must be 1 to 32 letters, digits, '-' or '_'", ErrInvalidInput, i)
+		}
+		if slices.ContainsFunc(normalized, func(v storage.Variant) bool { return v.Name == variant.Name }) {
+			return nil, fmt.Errorf("%w: variant %s is defined more than once", ErrInvalidInput, variant.Name)
+		}
+		if variant.Weight < 1 || variant.Weight > maxVariantWeight {
+			return nil, fmt.Errorf("%w: variants[%d] weight must be between 1 and %d", ErrInvalidInput, i, maxVariantWeight)
+		}
+		if !isAbsoluteURL(variant.URL) {
+			return nil, fmt.Errorf("%w: variants[%d] url must be an absolute URL", ErrInvalidInput, i)
+		}
+		normalized = append(normalized, variant)
+	}
+
+	return normalized, nil
+}
+
+// validVariantName keeps the names safe to store in the sticky cookie.
+func validVariantName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAbsoluteURL reports whether target has a scheme and a host.
+func isAbsoluteURL(target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && u.IsAbs() && u.Host != ""
+}
+
+// normalizeFolder turns " /team/campaign/ " into "team/campaign".
+func normalizeFolder(folder string) (string, error) {
+	folder = strings.Trim(strings.TrimSpace(folder), "/")
+	if folder == "" {
+		return "", nil
+	}
+
+	segments := strings.Split(folder, "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimSpace(segment)
+		if segments[i] == "" {
+			return "", fmt.Errorf("%w: folder must not contain empty segments", ErrInvalidInput)
+		}
+	}
+
+	return strings.Join(segments, "/"), nil
+}
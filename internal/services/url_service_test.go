@@ -0,0 +1,976 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/geoip"
+	"url_shortener/internal/reputation"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testActor is the user the actions in the tests are performed as.
+var testActor = storage.Actor{User: "user", IP: "192.0.2.1"}
+
+func TestSaveURLValidation(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	zero := 0
+
+	tests := []struct {
+		name          string
+		link          storage.URL
+		expectedSaved storage.URL
+		expectedErr   error
+	}{
+		{
+			name:          "defaults and normalization",
+			link:          storage.URL{URL: "https://example.com", Alias: "test", Tags: []string{" Promo", "spring", "promo"}, Folder: "/marketing/2025/"},
+			expectedSaved: storage.URL{URL: "https://example.com", Alias: "test", RedirectType: 302, Tags: []string{"promo", "spring"}, Folder: "marketing/2025"},
+		},
+		{
+			name:        "non-positive max visits",
+			link:        storage.URL{URL: "https://example.com", MaxVisits: &zero},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "expiry in the past",
+			link:        storage.URL{URL: "https://example.com", ExpiresAt: &past},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unsupported redirect type",
+			link:        storage.URL{URL: "https://example.com", RedirectType: 303},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "empty tag",
+			link:        storage.URL{URL: "https://example.com", Tags: []string{" "}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "empty folder segment",
+			link:        storage.URL{URL: "https://example.com", Folder: "marketing//2025"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:          "geo targets are uppercased",
+			link:          storage.URL{URL: "https://example.com", Alias: "geo", GeoTargets: map[string]string{"de": "https://example.com/de"}},
+			expectedSaved: storage.URL{URL: "https://example.com", Alias: "geo", RedirectType: 302, Tags: []string{}, GeoTargets: map[string]string{"DE": "https://example.com/de"}},
+		},
+		{
+			name:        "invalid country code",
+			link:        storage.URL{URL: "https://example.com", GeoTargets: map[string]string{"Germany": "https://example.com/de"}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "relative geo target",
+			link:        storage.URL{URL: "https://example.com", GeoTargets: map[string]string{"DE": "/de"}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "device rules are lowercased",
+			link: storage.URL{URL: "https://example.com", Alias: "app", DeviceRules: []storage.DeviceRule{{OS: " iOS", URL: "https://apps.apple.com/app/id1"}}},
+			expectedSaved: storage.URL{URL: "https://example.com", Alias: "app", RedirectType: 302, Tags: []string{},
+				DeviceRules: []storage.DeviceRule{{OS: "ios", URL: "https://apps.apple.com/app/id1"}}},
+		},
+		{
+			name:        "device rule without condition",
+			link:        storage.URL{URL: "https://example.com", DeviceRules: []storage.DeviceRule{{URL: "https://example.com/app"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unknown device class",
+			link:        storage.URL{URL: "https://example.com", DeviceRules: []storage.DeviceRule{{Device: "watch", URL: "https://example.com/app"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "variant names are lowercased",
+			link: storage.URL{URL: "https://example.com", Alias: "ab", StickyVariants: true,
+				Variants: []storage.Variant{{Name: "A", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 3}}},
+			expectedSaved: storage.URL{URL: "https://example.com", Alias: "ab", RedirectType: 302, Tags: []string{}, StickyVariants: true,
+				Variants: []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 3}}},
+		},
+		{
+			name:        "single variant",
+			link:        storage.URL{URL: "https://example.com", Variants: []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "duplicate variant name",
+			link: storage.URL{URL: "https://example.com",
+				Variants: []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "A", URL: "https://example.com/b", Weight: 1}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "zero variant weight",
+			link: storage.URL{URL: "https://example.com",
+				Variants: []storage.Variant{{Name: "a", URL: "https://example.com/a", Weight: 0}, {Name: "b", URL: "https://example.com/b", Weight: 1}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "invalid variant name",
+			link: storage.URL{URL: "https://example.com",
+				Variants: []storage.Variant{{Name: "a b", URL: "https://example.com/a", Weight: 1}, {Name: "c", URL: "https://example.com/b", Weight: 1}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "sticky without variants",
+			link:        storage.URL{URL: "https://example.com", StickyVariants: true},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "rules are normalized",
+			link: storage.URL{URL: "https://example.com", Alias: "rules", Rules: []storage.RedirectRule{
+				{Days: []string{"Sat", "sun", "sat"}, Languages: []string{" DE-at"}, Query: map[string]string{}, URL: "https://example.com/weekend"},
+			}},
+			expectedSaved: storage.URL{URL: "https://example.com", Alias: "rules", RedirectType: 302, Tags: []string{}, Rules: []storage.RedirectRule{
+				{Days: []string{"sat", "sun"}, Languages: []string{"de-at"}, URL: "https://example.com/weekend"},
+			}},
+		},
+		{
+			name:        "rule without condition",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{Query: map[string]string{}, URL: "https://example.com/all"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "rule window without end",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{From: "09:00", URL: "https://example.com/day"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "rule window with invalid time",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{From: "9am", To: "17:00", URL: "https://example.com/day"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unknown rule timezone",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{Days: []string{"mon"}, Timezone: "Mars/Olympus", URL: "https://example.com/mon"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "rule date range ending before it starts",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{After: &past, Before: &past, URL: "https://example.com/old"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "invalid rule language",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{Languages: []string{"german"}, URL: "https://example.de"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unknown rule day",
+			link:        storage.URL{URL: "https://example.com", Rules: []storage.RedirectRule{{Days: []string{"monday"}, URL: "https://example.com/mon"}}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "query policies are normalized",
+			link: storage.URL{URL: "https://example.com", Alias: "utm", QueryPassthrough: "drop", UTM: map[string]string{"UTM_Source": "news"}},
+			expectedSaved: storage.URL{URL: "https://example.com", Alias: "utm", RedirectType: 302, Tags: []string{},
+				UTM: map[string]string{"utm_source": "news"}},
+		},
+		{
+			name:        "unknown query passthrough",
+			link:        storage.URL{URL: "https://example.com", QueryPassthrough: "append"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unknown utm parameter",
+			link:        storage.URL{URL: "https://example.com", UTM: map[string]string{"ref": "news"}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "empty utm parameter",
+			link:        storage.URL{URL: "https://example.com", UTM: map[string]string{"utm_medium": " "}},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "reserved alias",
+			link:        storage.URL{URL: "https://example.com", Alias: "API"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:          "prefix link",
+			link:          storage.URL{URL: "https://docs.example.com", Alias: "docs/v2", Prefix: true},
+			expectedSaved: storage.URL{URL: "https://docs.example.com", Alias: "docs/v2", Prefix: true, RedirectType: 302, Tags: []string{}},
+		},
+		{
+			name:        "reserved first segment",
+			link:        storage.URL{URL: "https://example.com", Alias: "api/docs"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "empty alias segment",
+			link:        storage.URL{URL: "https://example.com", Alias: "docs//v2"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "dot alias segment",
+			link:        storage.URL{URL: "https://example.com", Alias: "docs/../admin"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "private destination",
+			link:        storage.URL{URL: "http://169.254.169.254/latest/meta-data", Alias: "test"},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "private destination of a rule",
+			link:        storage.URL{URL: "https://example.com", Alias: "test", DeviceRules: []storage.DeviceRule{{OS: "android", URL: "http://localhost/app"}}},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			if tt.expectedErr == nil {
+				mockStorage.On("SaveURL", mock.Anything, tt.expectedSaved, false, testActor).Return(tt.expectedSaved, nil)
+			}
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			_, err := service.SaveURL(context.Background(), tt.link, false, testActor)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSaveURLGeneratedAlias(t *testing.T) {
+	hash := destinationHash("https://example.com/")
+	existing := storage.URL{Alias: "k3Xy9Zp", URL: "https://example.com", Owner: "bot", RedirectType: 302, DestinationHash: hash, Reused: true}
+
+	tests := []struct {
+		name          string
+		reuseExisting bool
+		mockSetup     func(*mocks.URLStorage)
+		expectedAlias string
+		expectedReuse bool
+	}{
+		{
+			name: "alias is generated",
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("SaveURL", mock.Anything, mock.MatchedBy(func(link storage.URL) bool { return link.Alias == "2222222" && link.DestinationHash == hash }), false, testActor).
+					Return(storage.URL{Alias: "2222222"}, nil)
+			},
+			expectedAlias: "2222222",
+		},
+		{
+			name: "taken alias is drawn again",
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("SaveURL", mock.Anything, mock.Anything, false, testActor).Return(storage.URL{}, storage.ErrURLExist).Once()
+				m.On("SaveURL", mock.Anything, mock.Anything, false, testActor).Return(storage.URL{Alias: "2222222"}, nil).Once()
+			},
+			expectedAlias: "2222222",
+		},
+		{
+			name:          "existing link is reused",
+			reuseExisting: true,
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("SaveURL", mock.Anything, mock.MatchedBy(func(link storage.URL) bool { return link.Owner == "bot" && link.DestinationHash == hash }), true, testActor).
+					Return(existing, nil)
+			},
+			expectedAlias: "k3Xy9Zp",
+			expectedReuse: true,
+		},
+		{
+			name:          "nothing to reuse",
+			reuseExisting: true,
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("SaveURL", mock.Anything, mock.Anything, true, testActor).Return(storage.URL{Alias: "2222222"}, nil)
+			},
+			expectedAlias: "2222222",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			tt.mockSetup(mockStorage)
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			service.(*urlService).randIntN = func(int) int { return 0 }
+			saved, err := service.SaveURL(context.Background(), storage.URL{URL: "https://example.com", Owner: "bot"}, tt.reuseExisting, testActor)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAlias, saved.Alias)
+			assert.Equal(t, tt.expectedReuse, saved.Reused)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSaveURLGeneratedAliasAttempts(t *testing.T) {
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("SaveURL", mock.Anything, mock.Anything, false, testActor).Return(storage.URL{}, storage.ErrURLExist)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	_, err := service.SaveURL(context.Background(), storage.URL{URL: "https://example.com"}, false, testActor)
+
+	assert.ErrorIs(t, err, ErrURLAlreadyExists)
+	mockStorage.AssertNumberOfCalls(t, "SaveURL", maxAliasAttempts)
+}
+
+func TestSaveURLReuseWithSettings(t *testing.T) {
+	maxVisits := 5
+
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("SaveURL", mock.Anything, mock.MatchedBy(func(link storage.URL) bool { return link.DestinationHash == "" }), false, testActor).
+		Return(storage.URL{Alias: "2222222"}, nil)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	service.(*urlService).randIntN = func(int) int { return 0 }
+
+	_, err := service.SaveURL(context.Background(), storage.URL{URL: "https://example.com", MaxVisits: &maxVisits}, true, testActor)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = service.SaveURL(context.Background(), storage.URL{URL: "https://example.com", Tags: []string{"promo"}}, true, testActor)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	// links with settings are never reused later on
+	saved, err := service.SaveURL(context.Background(), storage.URL{URL: "https://example.com", MaxVisits: &maxVisits}, false, testActor)
+	require.NoError(t, err)
+	assert.Equal(t, "2222222", saved.Alias)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestSaveURLCustomAliasIsNotReused(t *testing.T) {
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("SaveURL", mock.Anything, mock.MatchedBy(func(link storage.URL) bool { return link.Alias == "docs" && link.DestinationHash == "" }), false, testActor).
+		Return(storage.URL{Alias: "docs"}, nil)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	saved, err := service.SaveURL(context.Background(), storage.URL{URL: "https://example.com", Alias: "docs"}, true, testActor)
+
+	require.NoError(t, err)
+	assert.False(t, saved.Reused)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestSaveURLReputation(t *testing.T) {
+	checker := reputationChecker(func(rawURL string) (reputation.Verdict, error) {
+		switch rawURL {
+		case "https://evil.example/":
+			return reputation.Verdict{Threat: "MALWARE", Source: "malware"}, nil
+		case "https://flaky.example/":
+			return reputation.Verdict{}, fmt.Errorf("%w: remote: timeout", reputation.ErrUnavailable)
+		}
+		return reputation.Verdict{}, nil
+	})
+
+	tests := []struct {
+		name        string
+		link        storage.URL
+		expectedErr error
+	}{
+		{name: "clean destination", link: storage.URL{URL: "https://example.com/", Alias: "test"}},
+		{name: "malicious destination", link: storage.URL{URL: "https://evil.example/", Alias: "test"}, expectedErr: ErrInvalidInput},
+		{name: "malicious variant", link: storage.URL{URL: "https://example.com/", Alias: "test", Variants: []storage.Variant{{Name: "b", URL: "https://evil.example/", Weight: 1}}}, expectedErr: ErrInvalidInput},
+		{name: "checker unavailable", link: storage.URL{URL: "https://flaky.example/", Alias: "test"}, expectedErr: ErrReputationUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			if tt.expectedErr == nil {
+				mockStorage.On("SaveURL", mock.Anything, mock.Anything, false, testActor).Return(tt.link, nil)
+			}
+
+			service := NewURLService(mockStorage, nil, nil, nil, checker, config.Config{}, slog.Default())
+			_, err := service.SaveURL(context.Background(), tt.link, false, testActor)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateURLReputation(t *testing.T) {
+	checker := reputationChecker(func(string) (reputation.Verdict, error) {
+		return reputation.Verdict{Threat: "SOCIAL_ENGINEERING", Source: "phishing"}, nil
+	})
+
+	service := NewURLService(new(mocks.URLStorage), nil, nil, nil, checker, config.Config{}, slog.Default())
+	_, err := service.UpdateURL(context.Background(), storage.URL{URL: "https://evil.example/", Alias: "test"}, testActor)
+
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorContains(t, err, "destination https://evil.example/ is flagged as SOCIAL_ENGINEERING by phishing")
+}
+
+// reputationChecker adapts a function to the reputation.Checker interface.
+type reputationChecker func(rawURL string) (reputation.Verdict, error)
+
+func (f reputationChecker) Check(_ context.Context, rawURL string) (reputation.Verdict, error) {
+	return f(rawURL)
+}
+
+func TestGetURLErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		storageErr  error
+		expectedErr error
+	}{
+		{name: "not found", storageErr: storage.ErrURLNotFound, expectedErr: ErrURLNotFound},
+		{name: "exhausted", storageErr: storage.ErrURLExhausted, expectedErr: ErrURLGone},
+		{name: "expired", storageErr: storage.ErrURLExpired, expectedErr: ErrURLGone},
+		{name: "needs confirmation", storageErr: storage.ErrURLNeedsConfirmation, expectedErr: ErrURLNeedsPreview},
+		{name: "blocked", storageErr: storage.ErrURLBlocked, expectedErr: ErrURLBlocked},
+		{name: "storage failure", storageErr: errors.New("connection refused"), expectedErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("GetURL", mock.Anything, "", "test", mock.Anything).Return(storage.URL{}, tt.storageErr)
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			_, err := service.GetURL(context.Background(), "", "test", false, Visitor{})
+
+			assert.Error(t, err)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestBlockURL(t *testing.T) {
+	blockedAt := time.Now()
+	blocked := storage.URL{Alias: "test", URL: "https://example.com", BlockedAt: &blockedAt, BlockReason: "phishing"}
+
+	tests := []struct {
+		name        string
+		reason      string
+		mockSetup   func(*mocks.URLStorage)
+		expectedErr error
+	}{
+		{
+			name:   "blocked",
+			reason: " phishing ",
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("BlockURL", mock.Anything, "", "test", "phishing", testActor).Return(blocked, nil)
+			},
+		},
+		{
+			name:        "reason too long",
+			reason:      strings.Repeat("a", maxReasonLength+1),
+			mockSetup:   func(*mocks.URLStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name: "not found",
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("BlockURL", mock.Anything, "", "test", "", testActor).Return(storage.URL{}, storage.ErrURLNotFound)
+			},
+			expectedErr: ErrURLNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			tt.mockSetup(mockStorage)
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			link, err := service.BlockURL(context.Background(), "", "test", tt.reason, testActor)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, blocked, link)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnblockURL(t *testing.T) {
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("UnblockURL", mock.Anything, "", "missing", testActor).Return(storage.URL{}, storage.ErrURLNotFound)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	_, err := service.UnblockURL(context.Background(), "", "missing", testActor)
+
+	assert.ErrorIs(t, err, ErrURLNotFound)
+}
+
+func TestGetURLGeoTargets(t *testing.T) {
+	// the fixture maps 81.2.69.0/24 to GB and 216.160.83.0/24 to US
+	geo, err := geoip.Open("../geoip/testdata/GeoLite2-Country-Test.mmdb")
+	require.NoError(t, err)
+	t.Cleanup(func() { geo.Close() })
+
+	link := storage.URL{Alias: "test", URL: "https://example.com", GeoTargets: map[string]string{"GB": "https://example.co.uk", "JP": "https://example.jp"}}
+
+	tests := []struct {
+		name        string
+		geo         geoip.Resolver
+		ip          string
+		expectedURL string
+	}{
+		{name: "targeted country", geo: geo, ip: "81.2.69.142", expectedURL: "https://example.co.uk"},
+		{name: "country without override", geo: geo, ip: "216.160.83.56", expectedURL: "https://example.com"},
+		{name: "unknown address", geo: geo, ip: "10.0.0.1", expectedURL: "https://example.com"},
+		{name: "invalid address", geo: geo, ip: "", expectedURL: "https://example.com"},
+		{name: "no database", geo: nil, ip: "81.2.69.142", expectedURL: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("GetURL", mock.Anything, "", "test", false).Return(link, nil)
+
+			service := NewURLService(mockStorage, tt.geo, nil, nil, nil, config.Config{}, slog.Default())
+			got, err := service.GetURL(context.Background(), "", "test", false, Visitor{IP: tt.ip})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, got.URL)
+		})
+	}
+}
+
+func TestGetURLDeviceRules(t *testing.T) {
+	const (
+		iphone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+		android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+		desktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+		bot     = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	)
+
+	geo, err := geoip.Open("../geoip/testdata/GeoLite2-Country-Test.mmdb")
+	require.NoError(t, err)
+	t.Cleanup(func() { geo.Close() })
+
+	link := storage.URL{Alias: "app", URL: "https://example.com", GeoTargets: map[string]string{"GB": "https://example.co.uk"},
+		DeviceRules: []storage.DeviceRule{
+			{Device: "bot", URL: "https://example.com/crawlers"},
+			{OS: "ios", URL: "https://apps.apple.com/app/id1"},
+			{OS: "android", Device: "mobile", URL: "https://play.google.com/store/apps/details?id=com.example"},
+		}}
+
+	tests := []struct {
+		name        string
+		visitor     Visitor
+		expectedURL string
+	}{
+		{name: "ios", visitor: Visitor{UserAgent: iphone}, expectedURL: "https://apps.apple.com/app/id1"},
+		{name: "android", visitor: Visitor{UserAgent: android}, expectedURL: "https://play.google.com/store/apps/details?id=com.example"},
+		{name: "bot", visitor: Visitor{UserAgent: bot}, expectedURL: "https://example.com/crawlers"},
+		{name: "device rule wins over country", visitor: Visitor{IP: "81.2.69.142", UserAgent: iphone}, expectedURL: "https://apps.apple.com/app/id1"},
+		{name: "desktop falls back to country", visitor: Visitor{IP: "81.2.69.142", UserAgent: desktop}, expectedURL: "https://example.co.uk"},
+		{name: "desktop falls back to default", visitor: Visitor{IP: "216.160.83.56", UserAgent: desktop}, expectedURL: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("GetURL", mock.Anything, "", "app", false).Return(link, nil)
+
+			service := NewURLService(mockStorage, geo, nil, nil, nil, config.Config{}, slog.Default())
+			got, err := service.GetURL(context.Background(), "", "app", false, tt.visitor)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, got.URL)
+		})
+	}
+}
+
+func TestGetURLRules(t *testing.T) {
+	const iphone = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+
+	link := storage.URL{Alias: "rules", URL: "https://example.com",
+		Rules: []storage.RedirectRule{
+			{Query: map[string]string{"ref": "partner"}, URL: "https://example.com/partner"},
+			{Days: []string{"sat", "sun"}, URL: "https://example.com/weekend"},
+		},
+		DeviceRules: []storage.DeviceRule{{OS: "ios", URL: "https://apps.apple.com/app/id1"}}}
+	saturday := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		visitor     Visitor
+		expectedURL string
+	}{
+		{name: "query rule", now: monday, visitor: Visitor{Query: url.Values{"ref": {"partner"}}}, expectedURL: "https://example.com/partner"},
+		{name: "rule wins over device rule", now: saturday, visitor: Visitor{UserAgent: iphone}, expectedURL: "https://example.com/weekend"},
+		{name: "falls through to device rule", now: monday, visitor: Visitor{UserAgent: iphone}, expectedURL: "https://apps.apple.com/app/id1"},
+		{name: "falls through to default", now: monday, expectedURL: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("GetURL", mock.Anything, "", "rules", false).Return(link, nil)
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			service.(*urlService).now = func() time.Time { return tt.now }
+			got, err := service.GetURL(context.Background(), "", "rules", false, tt.visitor)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, got.URL)
+		})
+	}
+}
+
+func TestGetURLQueryPassthrough(t *testing.T) {
+	link := storage.URL{Alias: "promo", URL: "https://example.com/landing#offer", QueryPassthrough: storage.QueryMerge,
+		UTM:        map[string]string{"utm_medium": "short"},
+		GeoTargets: map[string]string{"GB": "https://example.co.uk/landing?lang=en"}}
+
+	geo, err := geoip.Open("../geoip/testdata/GeoLite2-Country-Test.mmdb")
+	require.NoError(t, err)
+	t.Cleanup(func() { geo.Close() })
+
+	tests := []struct {
+		name        string
+		visitor     Visitor
+		expectedURL string
+	}{
+		{name: "default destination", visitor: Visitor{Query: url.Values{"utm_source": {"x"}}}, expectedURL: "https://example.com/landing?utm_source=x&utm_medium=short#offer"},
+		{name: "targeted destination", visitor: Visitor{IP: "81.2.69.142", Query: url.Values{"lang": {"de"}}}, expectedURL: "https://example.co.uk/landing?lang=en&utm_medium=short"},
+		{name: "without query", expectedURL: "https://example.com/landing?utm_medium=short#offer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("GetURL", mock.Anything, "", "promo", false).Return(link, nil)
+
+			service := NewURLService(mockStorage, geo, nil, nil, nil, config.Config{}, slog.Default())
+			got, err := service.GetURL(context.Background(), "", "promo", false, tt.visitor)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, got.URL)
+		})
+	}
+}
+
+func TestGetURLPrefix(t *testing.T) {
+	link := storage.URL{Alias: "docs", URL: "https://docs.example.com/v2?lang=en#top", Prefix: true, QueryPassthrough: storage.QueryMerge}
+
+	tests := []struct {
+		name        string
+		path        string
+		resolved    string
+		query       url.Values
+		expectedURL string
+		expectedErr error
+	}{
+		{name: "link itself", path: "docs", expectedURL: "https://docs.example.com/v2?lang=en#top"},
+		{name: "path is appended", path: "docs/getting-started/install", resolved: "docs", expectedURL: "https://docs.example.com/v2/getting-started/install?lang=en#top"},
+		{name: "trailing slash is kept", path: "docs/faq/", resolved: "docs", expectedURL: "https://docs.example.com/v2/faq/?lang=en#top"},
+		{name: "query is forwarded after the path", path: "docs/search", resolved: "docs", query: url.Values{"q": {"install"}}, expectedURL: "https://docs.example.com/v2/search?lang=en&q=install#top"},
+		{name: "dot segments are not forwarded", path: "docs/../admin", resolved: "docs", expectedErr: ErrURLNotFound},
+		{name: "no prefix link", path: "blog/post", expectedErr: ErrURLNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			if tt.resolved != "" {
+				mockStorage.On("ResolvePath", mock.Anything, "", tt.path).Return(tt.resolved, nil)
+			} else if tt.path != "docs" {
+				mockStorage.On("ResolvePath", mock.Anything, "", tt.path).Return("", storage.ErrURLNotFound)
+			}
+			if tt.expectedErr == nil {
+				mockStorage.On("GetURL", mock.Anything, "", "docs", false).Return(link, nil)
+			}
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			got, err := service.GetURL(context.Background(), "", tt.path, false, Visitor{Query: tt.query})
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedURL, got.URL)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetURLFoldCase(t *testing.T) {
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("ResolvePath", mock.Anything, "", "docs/getting-started").Return("docs", nil)
+	mockStorage.On("GetURL", mock.Anything, "", "docs", false).Return(storage.URL{Alias: "docs", URL: "https://docs.example.com", Prefix: true}, nil)
+	mockStorage.On("GetURLInfo", mock.Anything, "", "promo").Return(storage.URL{Alias: "promo", URL: "https://example.com"}, nil)
+
+	aliases, err := NewAliasPolicy(config.Aliases{FoldCase: true})
+	require.NoError(t, err)
+	service := NewURLService(mockStorage, nil, aliases, nil, nil, config.Config{}, slog.Default())
+
+	got, err := service.GetURL(context.Background(), "", "Docs/Getting-Started", false, Visitor{})
+	require.NoError(t, err)
+	// the forwarded path keeps its case
+	assert.Equal(t, "https://docs.example.com/Getting-Started", got.URL)
+
+	_, err = service.GetURLInfo(context.Background(), "", "PROMO")
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestMatchRuleDryRun(t *testing.T) {
+	link := storage.URL{Alias: "rules", URL: "https://example.com", Rules: []storage.RedirectRule{
+		{Languages: []string{"fr"}, URL: "https://example.fr"},
+		{Languages: []string{"de"}, URL: "https://example.de"},
+	}}
+
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("GetURLInfo", mock.Anything, "", "rules").Return(link, nil)
+	mockStorage.On("GetURLInfo", mock.Anything, "", "missing").Return(storage.URL{}, storage.ErrURLNotFound)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+
+	match, err := service.MatchRule(context.Background(), "", "rules", Visitor{AcceptLanguage: "de-CH, fr;q=0.5"}, time.Now())
+	require.NoError(t, err)
+	assert.True(t, match.Matched)
+	assert.Equal(t, 1, match.Index)
+	assert.Equal(t, "https://example.de", match.Rule.URL)
+
+	match, err = service.MatchRule(context.Background(), "", "rules", Visitor{AcceptLanguage: "en"}, time.Now())
+	require.NoError(t, err)
+	assert.False(t, match.Matched)
+
+	_, err = service.MatchRule(context.Background(), "", "missing", Visitor{}, time.Now())
+	assert.ErrorIs(t, err, ErrURLNotFound)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetURLVariants(t *testing.T) {
+	link := storage.URL{ID: 7, Alias: "ab", URL: "https://example.com",
+		DeviceRules: []storage.DeviceRule{{Device: "bot", URL: "https://example.com/crawlers"}},
+		Variants: []storage.Variant{
+			{Name: "a", URL: "https://example.com/a", Weight: 1},
+			{Name: "b", URL: "https://example.com/b", Weight: 3},
+		}}
+	sticky := link
+	sticky.StickyVariants = true
+
+	tests := []struct {
+		name            string
+		link            storage.URL
+		visitor         Visitor
+		draw            int
+		expectedURL     string
+		expectedVariant string
+	}{
+		{name: "lowest draw", link: link, draw: 0, expectedURL: "https://example.com/a", expectedVariant: "a"},
+		{name: "draw past the first weight", link: link, draw: 1, expectedURL: "https://example.com/b", expectedVariant: "b"},
+		{name: "highest draw", link: link, draw: 3, expectedURL: "https://example.com/b", expectedVariant: "b"},
+		{name: "cookie ignored when not sticky", link: link, visitor: Visitor{Variant: "a"}, draw: 3, expectedURL: "https://example.com/b", expectedVariant: "b"},
+		{name: "sticky visitor keeps variant", link: sticky, visitor: Visitor{Variant: "a"}, draw: 3, expectedURL: "https://example.com/a", expectedVariant: "a"},
+		{name: "sticky visitor with removed variant", link: sticky, visitor: Visitor{Variant: "c"}, draw: 3, expectedURL: "https://example.com/b", expectedVariant: "b"},
+		{name: "device rule wins over variants", link: link, visitor: Visitor{UserAgent: "Googlebot/2.1"}, expectedURL: "https://example.com/crawlers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("GetURL", mock.Anything, "", "ab", false).Return(tt.link, nil)
+			if tt.expectedVariant != "" {
+				mockStorage.On("CountVariantClick", mock.Anything, int64(7), tt.expectedVariant).Return(nil)
+			}
+
+			service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+			service.(*urlService).randIntN = func(n int) int {
+				assert.Equal(t, 4, n)
+				return tt.draw
+			}
+			got, err := service.GetURL(context.Background(), "", "ab", false, tt.visitor)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, got.URL)
+			assert.Equal(t, tt.expectedVariant, got.Variant)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetURLVariantClickFailure(t *testing.T) {
+	link := storage.URL{ID: 7, Alias: "ab", URL: "https://example.com", Variants: []storage.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 1},
+		{Name: "b", URL: "https://example.com/b", Weight: 1},
+	}}
+
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("GetURL", mock.Anything, "", "ab", false).Return(link, nil)
+	mockStorage.On("CountVariantClick", mock.Anything, int64(7), "a").Return(errors.New("connection refused"))
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	service.(*urlService).randIntN = func(int) int { return 0 }
+	got, err := service.GetURL(context.Background(), "", "ab", false, Visitor{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", got.URL)
+}
+
+func TestListURLsDefaults(t *testing.T) {
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("ListURLs", mock.Anything, storage.URLFilter{Tag: "promo", Folder: "marketing", Limit: defaultListLimit}).Return([]storage.URL{}, nil)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	_, err := service.ListURLs(context.Background(), storage.URLFilter{Tag: " Promo ", Folder: "/marketing/"})
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+
+	_, err = service.ListURLs(context.Background(), storage.URLFilter{Limit: maxListLimit + 1})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestListURLsDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   storage.URLFilter
+		expected storage.URLFilter
+	}{
+		{
+			name:     "default domain by name",
+			filter:   storage.URLFilter{Domain: "SHO.RT"},
+			expected: storage.URLFilter{Domain: "", Limit: defaultListLimit},
+		},
+		{
+			name:     "default domain",
+			filter:   storage.URLFilter{},
+			expected: storage.URLFilter{Domain: "", Limit: defaultListLimit},
+		},
+		{
+			name:     "custom domain",
+			filter:   storage.URLFilter{Domain: "Go.Brand.com"},
+			expected: storage.URLFilter{Domain: "go.brand.com", Limit: defaultListLimit},
+		},
+		{
+			name:     "all domains",
+			filter:   storage.URLFilter{AllDomains: true},
+			expected: storage.URLFilter{AllDomains: true, Limit: defaultListLimit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			mockStorage.On("ListURLs", mock.Anything, tt.expected).Return([]storage.URL{}, nil)
+
+			cfg := config.Config{HttpServer: config.HttpServer{DefaultDomain: "sho.rt"}}
+			service := NewURLService(mockStorage, nil, nil, nil, nil, cfg, slog.Default())
+			_, err := service.ListURLs(context.Background(), tt.filter)
+
+			require.NoError(t, err)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContextReachesStorage(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "request"))
+	cancel()
+
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("DeleteURL", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(key{}) == "request" && ctx.Err() == context.Canceled
+	}), "", "test", testActor).Return(context.Canceled)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+
+	assert.ErrorIs(t, service.DeleteURL(ctx, "", "test", testActor), context.Canceled)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	parentCtx, parent := otel.Tracer("test").Start(context.Background(), "request")
+
+	mockStorage := new(mocks.URLStorage)
+	mockStorage.On("GetURL", mock.MatchedBy(func(ctx context.Context) bool {
+		span := trace.SpanContextFromContext(ctx)
+		return span.TraceID() == parent.SpanContext().TraceID() && span.SpanID() != parent.SpanContext().SpanID()
+	}), "", "test", false).Return(storage.URL{Alias: "test"}, nil)
+	mockStorage.On("GetURL", mock.Anything, "", "missing", false).Return(storage.URL{}, storage.ErrURLNotFound)
+
+	service := NewURLService(mockStorage, nil, nil, nil, nil, config.Config{}, slog.Default())
+	_, err := service.GetURL(parentCtx, "", "test", false, Visitor{})
+	assert.NoError(t, err)
+	_, err = service.GetURL(parentCtx, "", "missing", false, Visitor{})
+	assert.ErrorIs(t, err, ErrURLNotFound)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	for _, span := range spans[:2] {
+		assert.Equal(t, "services.url_service.GetURL", span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestResolveDomain(t *testing.T) {
+	tests := []struct {
+		name           string
+		host           string
+		mockSetup      func(*mocks.URLStorage)
+		expectedDomain string
+	}{
+		{
+			name:           "default domain with port",
+			host:           "Sho.rt:8080",
+			mockSetup:      func(m *mocks.URLStorage) {},
+			expectedDomain: "",
+		},
+		{
+			name: "managed domain",
+			host: "go.brand.com",
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("DomainExists", mock.Anything, "go.brand.com").Return(true, nil)
+			},
+			expectedDomain: "go.brand.com",
+		},
+		{
+			name: "unknown host falls back to default domain",
+			host: "10.0.0.1:8080",
+			mockSetup: func(m *mocks.URLStorage) {
+				m.On("DomainExists", mock.Anything, "10.0.0.1").Return(false, nil)
+			},
+			expectedDomain: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.URLStorage)
+			tt.mockSetup(mockStorage)
+
+			cfg := config.Config{HttpServer: config.HttpServer{DefaultDomain: "sho.rt"}}
+			service := NewURLService(mockStorage, nil, nil, nil, nil, cfg, slog.Default())
+			domain, err := service.ResolveDomain(context.Background(), tt.host)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDomain, domain)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
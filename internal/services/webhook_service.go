@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/postgres"
+)
+
+type WebhookService interface {
+	SaveSubscription(ctx context.Context, sub storage.Subscription, actor storage.Actor) (storage.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]storage.Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64, actor storage.Actor) error
+	ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]storage.Delivery, error)
+}
+
+type webhookService struct {
+	webhookStorage postgres.WebhookStorage
+	log            *slog.Logger
+}
+
+func NewWebhookService(storage postgres.WebhookStorage, logger *slog.Logger) WebhookService {
+	return &webhookService{webhookStorage: storage, log: logger}
+}
+
+// SaveSubscription validates the endpoint and the events and generates a
+// secret when none is given.
+func (c *webhookService) SaveSubscription(ctx context.Context, sub storage.Subscription, actor storage.Actor) (storage.Subscription, error) {
+	const fn = "services.webhook_service.SaveSubscription"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	endpoint, err := url.Parse(sub.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		log.ErrorContext(ctx, "invalid webhook url", slog.String("url", sub.URL))
+		return storage.Subscription{}, fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidInput)
+	}
+
+	for _, event := range sub.Events {
+		if !slices.Contains(storage.WebhookEvents, event) {
+			log.ErrorContext(ctx, "unknown webhook event", slog.String("event", event))
+			return storage.Subscription{}, fmt.Errorf("%w: unknown event %s", ErrInvalidInput, event)
+		}
+	}
+	sub.Events = append([]string{}, sub.Events...)
+	slices.Sort(sub.Events)
+	sub.Events = slices.Compact(sub.Events)
+
+	if sub.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.ErrorContext(ctx, "failed to generate a secret", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+			return storage.Subscription{}, err
+		}
+		sub.Secret = hex.EncodeToString(secret)
+	}
+
+	saved, err := c.webhookStorage.SaveSubscription(ctx, sub, actor)
+	if err != nil {
+		log.ErrorContext(ctx, "server error during saving the subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return storage.Subscription{}, err
+	}
+
+	return saved, nil
+}
+
+func (c *webhookService) ListSubscriptions(ctx context.Context) ([]storage.Subscription, error) {
+	const fn = "services.webhook_service.ListSubscriptions"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	subs, err := c.webhookStorage.ListSubscriptions(ctx)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to list subscriptions", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (c *webhookService) DeleteSubscription(ctx context.Context, id int64, actor storage.Actor) error {
+	const fn = "services.webhook_service.DeleteSubscription"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	if err := c.webhookStorage.DeleteSubscription(ctx, id, actor); err != nil {
+		if errors.Is(err, storage.ErrSubscriptionNotFound) {
+			log.ErrorContext(ctx, "subscription was not found", slog.Int64("id", id))
+			return ErrSubscriptionNotFound
+		}
+		log.ErrorContext(ctx, "error trying to delete a subscription", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return err
+	}
+
+	return nil
+}
+
+func (c *webhookService) ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]storage.Delivery, error) {
+	const fn = "services.webhook_service.ListDeliveries"
+	log := c.log.With(
+		slog.String("fn", fn),
+	)
+
+	if filter.Limit < 0 || filter.Limit > maxListLimit || filter.Offset < 0 {
+		log.ErrorContext(ctx, "invalid pagination", slog.Int("limit", filter.Limit), slog.Int("offset", filter.Offset))
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d and offset must not be negative", ErrInvalidInput, maxListLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+
+	switch filter.Status {
+	case "", storage.DeliveryPending, storage.DeliveryDelivered, storage.DeliveryFailed:
+	default:
+		log.ErrorContext(ctx, "invalid delivery status", slog.String("status", filter.Status))
+		return nil, fmt.Errorf("%w: status must be one of pending, delivered, failed", ErrInvalidInput)
+	}
+
+	deliveries, err := c.webhookStorage.ListDeliveries(ctx, filter)
+	if err != nil {
+		log.ErrorContext(ctx, "error trying to list deliveries", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSaveSubscription(t *testing.T) {
+	tests := []struct {
+		name        string
+		sub         storage.Subscription
+		mockSetup   func(*mocks.WebhookStorage)
+		expectedErr error
+	}{
+		{
+			name: "events are sorted and deduplicated",
+			sub:  storage.Subscription{URL: "https://hooks.example.com/in", Secret: "s3cret", Events: []string{storage.EventLinkDeleted, storage.EventLinkCreated, storage.EventLinkDeleted}},
+			mockSetup: func(m *mocks.WebhookStorage) {
+				m.On("SaveSubscription", mock.Anything, storage.Subscription{URL: "https://hooks.example.com/in", Secret: "s3cret", Events: []string{storage.EventLinkCreated, storage.EventLinkDeleted}}, testActor).
+					Return(storage.Subscription{ID: 1}, nil)
+			},
+		},
+		{
+			name: "secret is generated",
+			sub:  storage.Subscription{URL: "http://hooks.example.com/in"},
+			mockSetup: func(m *mocks.WebhookStorage) {
+				m.On("SaveSubscription", mock.Anything, mock.MatchedBy(func(sub storage.Subscription) bool {
+					return len(sub.Secret) == 64
+				}), testActor).Return(storage.Subscription{ID: 1}, nil)
+			},
+		},
+		{
+			name:        "relative url",
+			sub:         storage.Subscription{URL: "/in"},
+			mockSetup:   func(m *mocks.WebhookStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unsupported scheme",
+			sub:         storage.Subscription{URL: "ftp://hooks.example.com/in"},
+			mockSetup:   func(m *mocks.WebhookStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "unknown event",
+			sub:         storage.Subscription{URL: "https://hooks.example.com/in", Events: []string{"link.visited"}},
+			mockSetup:   func(m *mocks.WebhookStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.WebhookStorage)
+			tt.mockSetup(mockStorage)
+
+			service := NewWebhookService(mockStorage, slog.Default())
+			_, err := service.SaveSubscription(context.Background(), tt.sub, testActor)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	mockStorage := new(mocks.WebhookStorage)
+	mockStorage.On("DeleteSubscription", mock.Anything, int64(3), testActor).Return(storage.ErrSubscriptionNotFound)
+
+	err := NewWebhookService(mockStorage, slog.Default()).DeleteSubscription(context.Background(), 3, testActor)
+
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestListDeliveries(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      storage.DeliveryFilter
+		mockSetup   func(*mocks.WebhookStorage)
+		expectedErr error
+	}{
+		{
+			name:   "default limit",
+			filter: storage.DeliveryFilter{Status: storage.DeliveryFailed},
+			mockSetup: func(m *mocks.WebhookStorage) {
+				m.On("ListDeliveries", mock.Anything, storage.DeliveryFilter{Status: storage.DeliveryFailed, Limit: defaultListLimit}).Return([]storage.Delivery{}, nil)
+			},
+		},
+		{
+			name:        "unknown status",
+			filter:      storage.DeliveryFilter{Status: "lost"},
+			mockSetup:   func(m *mocks.WebhookStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+		{
+			name:        "limit too large",
+			filter:      storage.DeliveryFilter{Limit: maxListLimit + 1},
+			mockSetup:   func(m *mocks.WebhookStorage) {},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(mocks.WebhookStorage)
+			tt.mockSetup(mockStorage)
+
+			_, err := NewWebhookService(mockStorage, slog.Default()).ListDeliveries(context.Background(), tt.filter)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
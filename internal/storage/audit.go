@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Administrative actions recorded in the audit log.
+const (
+	AuditLinkCreate    = "link.create"
+	AuditLinkUpdate    = "link.update"
+	AuditLinkDelete    = "link.delete"
+	AuditLinkBlock     = "link.block"
+	AuditLinkUnblock   = "link.unblock"
+	AuditDomainCreate  = "domain.create"
+	AuditDomainDelete  = "domain.delete"
+	AuditWebhookCreate = "webhook.create" // issues a signing secret, which is never logged
+	AuditWebhookDelete = "webhook.delete" // revokes the signing secret
+)
+
+// AuditActions lists every action the audit log can be filtered on.
+var AuditActions = []string{AuditLinkCreate, AuditLinkUpdate, AuditLinkDelete, AuditLinkBlock, AuditLinkUnblock, AuditDomainCreate, AuditDomainDelete, AuditWebhookCreate, AuditWebhookDelete}
+
+// Actor is who performed an administrative action.
+type Actor struct {
+	User string
+	IP   string // client address, empty when it is unknown
+}
+
+// AuditEntry is an action recorded in the append-only audit log.
+type AuditEntry struct {
+	ID     int64
+	Action string
+	Actor  Actor
+	// Target identifies the changed record: the alias of a link prefixed by
+	// its custom domain, the name of a domain or the id of a subscription.
+	Target    string
+	Before    json.RawMessage // state before the action, nil if it was created
+	After     json.RawMessage // state after the action, nil if it was deleted
+	CreatedAt time.Time
+}
+
+// AuditFilter narrows down a listing of the audit log.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
+	Offset int
+}
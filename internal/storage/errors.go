@@ -3,6 +3,14 @@ package storage
 import "errors"
 
 var (
-	ErrURLNotFound = errors.New("url now found")
-	ErrURLExist    = errors.New("url exists")
+	ErrURLNotFound          = errors.New("url now found")
+	ErrURLExist             = errors.New("url exists")
+	ErrURLExhausted         = errors.New("url visits limit reached")
+	ErrURLExpired           = errors.New("url expired")
+	ErrURLNeedsConfirmation = errors.New("url requires confirmation")
+	ErrURLBlocked           = errors.New("url blocked")
+	ErrDomainNotFound       = errors.New("domain not found")
+	ErrDomainExist          = errors.New("domain exists")
+	ErrDomainInUse          = errors.New("domain has links")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url_shortener/internal/storage"
+)
+
+// AuditStorage is an autogenerated mock type for the AuditStorage type
+type AuditStorage struct {
+	mock.Mock
+}
+
+// ListAudit provides a mock function with given fields: ctx, filter
+func (_m *AuditStorage) ListAudit(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEntry, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAudit")
+	}
+
+	var r0 []storage.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AuditFilter) ([]storage.AuditEntry, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AuditFilter) []storage.AuditEntry); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAuditStorage creates a new instance of AuditStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditStorage {
+	mock := &AuditStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
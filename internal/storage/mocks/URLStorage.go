@@ -2,24 +2,76 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url_shortener/internal/storage"
+)
 
 // URLStorage is an autogenerated mock type for the URLStorage type
 type URLStorage struct {
 	mock.Mock
 }
 
-// DeleteURL provides a mock function with given fields: alias
-func (_m *URLStorage) DeleteURL(alias string) error {
-	ret := _m.Called(alias)
+// BlockURL provides a mock function with given fields: ctx, domain, alias, reason, actor
+func (_m *URLStorage) BlockURL(ctx context.Context, domain string, alias string, reason string, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, reason, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BlockURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, reason, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, reason, actor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, storage.Actor) error); ok {
+		r1 = rf(ctx, domain, alias, reason, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountVariantClick provides a mock function with given fields: ctx, urlID, variant
+func (_m *URLStorage) CountVariantClick(ctx context.Context, urlID int64, variant string) error {
+	ret := _m.Called(ctx, urlID, variant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountVariantClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, urlID, variant)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteURL provides a mock function with given fields: ctx, domain, alias, actor
+func (_m *URLStorage) DeleteURL(ctx context.Context, domain string, alias string, actor storage.Actor) error {
+	ret := _m.Called(ctx, domain, alias, actor)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteURL")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Actor) error); ok {
+		r0 = rf(ctx, domain, alias, actor)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -27,27 +79,169 @@ func (_m *URLStorage) DeleteURL(alias string) error {
 	return r0
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLStorage) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// DomainExists provides a mock function with given fields: ctx, name
+func (_m *URLStorage) DomainExists(ctx context.Context, name string) (bool, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DomainExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURL provides a mock function with given fields: ctx, domain, alias, confirmed
+func (_m *URLStorage) GetURL(ctx context.Context, domain string, alias string, confirmed bool) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, confirmed)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetURL")
 	}
 
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, confirmed)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, confirmed)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool) error); ok {
+		r1 = rf(ctx, domain, alias, confirmed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURLInfo provides a mock function with given fields: ctx, domain, alias
+func (_m *URLStorage) GetURLInfo(ctx context.Context, domain string, alias string) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURLInfo")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.URL); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkStats provides a mock function with given fields: ctx, domain, alias
+func (_m *URLStorage) LinkStats(ctx context.Context, domain string, alias string) (storage.LinkStats, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkStats")
+	}
+
+	var r0 storage.LinkStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.LinkStats, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.LinkStats); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.LinkStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListURLs provides a mock function with given fields: ctx, filter
+func (_m *URLStorage) ListURLs(ctx context.Context, filter storage.URLFilter) ([]storage.URL, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListURLs")
+	}
+
+	var r0 []storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URLFilter) ([]storage.URL, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URLFilter) []storage.URL); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URLFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolvePath provides a mock function with given fields: ctx, domain, path
+func (_m *URLStorage) ResolvePath(ctx context.Context, domain string, path string) (string, error) {
+	ret := _m.Called(ctx, domain, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolvePath")
+	}
+
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, domain, path)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, domain, path)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, path)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,22 +249,118 @@ func (_m *URLStorage) GetURL(alias string) (string, error) {
 	return r0, r1
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLStorage) SaveURL(urlToSave string, alias string) error {
-	ret := _m.Called(urlToSave, alias)
+// SaveURL provides a mock function with given fields: ctx, link, reuseExisting, actor
+func (_m *URLStorage) SaveURL(ctx context.Context, link storage.URL, reuseExisting bool, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, link, reuseExisting, actor)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveURL")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(urlToSave, alias)
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, bool, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, link, reuseExisting, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, bool, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, link, reuseExisting, actor)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(storage.URL)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URL, bool, storage.Actor) error); ok {
+		r1 = rf(ctx, link, reuseExisting, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TagStats provides a mock function with given fields: ctx
+func (_m *URLStorage) TagStats(ctx context.Context) ([]storage.TagStats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TagStats")
+	}
+
+	var r0 []storage.TagStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.TagStats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.TagStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.TagStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnblockURL provides a mock function with given fields: ctx, domain, alias, actor
+func (_m *URLStorage) UnblockURL(ctx context.Context, domain string, alias string, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnblockURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, actor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.Actor) error); ok {
+		r1 = rf(ctx, domain, alias, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateURL provides a mock function with given fields: ctx, link, actor
+func (_m *URLStorage) UpdateURL(ctx context.Context, link storage.URL, actor storage.Actor) (storage.URL, error) {
+	ret := _m.Called(ctx, link, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, storage.Actor) (storage.URL, error)); ok {
+		return rf(ctx, link, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, storage.Actor) storage.URL); ok {
+		r0 = rf(ctx, link, actor)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URL, storage.Actor) error); ok {
+		r1 = rf(ctx, link, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewURLStorage creates a new instance of URLStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -0,0 +1,242 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url_shortener/internal/storage"
+
+	time "time"
+)
+
+// WebhookStorage is an autogenerated mock type for the WebhookStorage type
+type WebhookStorage struct {
+	mock.Mock
+}
+
+// ClaimDeliveries provides a mock function with given fields: ctx, limit, lease
+func (_m *WebhookStorage) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]storage.PendingDelivery, error) {
+	ret := _m.Called(ctx, limit, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDeliveries")
+	}
+
+	var r0 []storage.PendingDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) ([]storage.PendingDelivery, error)); ok {
+		return rf(ctx, limit, lease)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) []storage.PendingDelivery); ok {
+		r0 = rf(ctx, limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.PendingDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = rf(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteSubscription provides a mock function with given fields: ctx, id, actor
+func (_m *WebhookStorage) DeleteSubscription(ctx context.Context, id int64, actor storage.Actor) error {
+	ret := _m.Called(ctx, id, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, storage.Actor) error); ok {
+		r0 = rf(ctx, id, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DispatchEvents provides a mock function with given fields: ctx
+func (_m *WebhookStorage) DispatchEvents(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DispatchEvents")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnqueueExpired provides a mock function with given fields: ctx
+func (_m *WebhookStorage) EnqueueExpired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnqueueExpired")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeliveries provides a mock function with given fields: ctx, filter
+func (_m *WebhookStorage) ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]storage.Delivery, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeliveries")
+	}
+
+	var r0 []storage.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.DeliveryFilter) ([]storage.Delivery, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.DeliveryFilter) []storage.Delivery); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.DeliveryFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSubscriptions provides a mock function with given fields: ctx
+func (_m *WebhookStorage) ListSubscriptions(ctx context.Context) ([]storage.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSubscriptions")
+	}
+
+	var r0 []storage.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordAttempt provides a mock function with given fields: ctx, attempt
+func (_m *WebhookStorage) RecordAttempt(ctx context.Context, attempt storage.DeliveryAttempt) error {
+	ret := _m.Called(ctx, attempt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordAttempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.DeliveryAttempt) error); ok {
+		r0 = rf(ctx, attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveSubscription provides a mock function with given fields: ctx, sub, actor
+func (_m *WebhookStorage) SaveSubscription(ctx context.Context, sub storage.Subscription, actor storage.Actor) (storage.Subscription, error) {
+	ret := _m.Called(ctx, sub, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSubscription")
+	}
+
+	var r0 storage.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Subscription, storage.Actor) (storage.Subscription, error)); ok {
+		return rf(ctx, sub, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Subscription, storage.Actor) storage.Subscription); ok {
+		r0 = rf(ctx, sub, actor)
+	} else {
+		r0 = ret.Get(0).(storage.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Subscription, storage.Actor) error); ok {
+		r1 = rf(ctx, sub, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWebhookStorage creates a new instance of WebhookStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookStorage {
+	mock := &WebhookStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
+)
+
+type AuditStorage interface {
+	ListAudit(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEntry, error)
+}
+
+var _ AuditStorage = (*Storage)(nil) // check if Storage implements AuditStorage interface
+
+// recordAudit appends an action to the audit log. It runs in the transaction
+// of the action, so only committed changes are recorded.
+func recordAudit(ctx context.Context, tx *sql.Tx, entry storage.AuditEntry) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO audit_log(action, actor, client_ip, target, old_value, new_value)
+	VALUES($1, $2, $3, $4, $5::jsonb, $6::jsonb)`,
+		entry.Action, entry.Actor.User, entry.Actor.IP, entry.Target, jsonArg(entry.Before), jsonArg(entry.After))
+	return err
+}
+
+// jsonArg passes a JSON document as a query argument, nil as NULL.
+func jsonArg(doc []byte) any {
+	if doc == nil {
+		return nil
+	}
+	return string(doc)
+}
+
+// linkTarget is the audit target of a link.
+func linkTarget(domain string, alias string) string {
+	if domain == "" {
+		return alias
+	}
+	return domain + "/" + alias
+}
+
+// linkSnapshot returns the JSON state of a link and locks it for the rest of
+// the transaction, nil if there is no such link.
+func linkSnapshot(ctx context.Context, tx *sql.Tx, domain string, alias string) ([]byte, error) {
+	var snapshot []byte
+	err := tx.QueryRowContext(ctx, `SELECT `+linkPayload("url")+` FROM url WHERE domain = $1 AND alias = $2 FOR UPDATE`, domain, alias).Scan(&snapshot)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return snapshot, err
+}
+
+// ListAudit returns the audit log, newest first.
+func (s *Storage) ListAudit(ctx context.Context, filter storage.AuditFilter) (_ []storage.AuditEntry, err error) {
+	const fn = "storage.postgres.ListAudit"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Actor != "" {
+		where = append(where, "actor = "+arg(filter.Actor))
+	}
+	if filter.Action != "" {
+		where = append(where, "action = "+arg(filter.Action))
+	}
+	if filter.Target != "" {
+		where = append(where, "target = "+arg(filter.Target))
+	}
+	if filter.Since != nil {
+		where = append(where, "created_at >= "+arg(*filter.Since))
+	}
+	if filter.Until != nil {
+		where = append(where, "created_at < "+arg(*filter.Until))
+	}
+
+	query := "SELECT id, action, actor, client_ip, target, old_value, new_value, created_at FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT " + arg(filter.Limit) + " OFFSET " + arg(filter.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	entries := []storage.AuditEntry{}
+	for rows.Next() {
+		var entry storage.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.Actor.User, &entry.Actor.IP, &entry.Target, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		entry.Before, entry.After = before, after
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// BlockURL makes the link serve a warning page with the reason instead of
+// redirecting. Blocking a blocked link replaces its reason.
+func (s *Storage) BlockURL(ctx context.Context, domain string, alias string, reason string, actor storage.Actor) (_ storage.URL, err error) {
+	const fn = "storage.postgres.BlockURL"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	link, err := s.setBlocked(ctx, domain, alias, true, reason, storage.AuditLinkBlock, actor)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return link, nil
+}
+
+// UnblockURL makes a blocked link redirect again.
+func (s *Storage) UnblockURL(ctx context.Context, domain string, alias string, actor storage.Actor) (_ storage.URL, err error) {
+	const fn = "storage.postgres.UnblockURL"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	link, err := s.setBlocked(ctx, domain, alias, false, "", storage.AuditLinkUnblock, actor)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return link, nil
+}
+
+// setBlocked blocks or unblocks the link in a transaction that records the
+// link.updated event and the audit entry of action.
+func (s *Storage) setBlocked(ctx context.Context, domain string, alias string, blocked bool, reason string, action string, actor storage.Actor) (storage.URL, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := linkSnapshot(ctx, tx, domain, alias)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	if before == nil {
+		return storage.URL{}, storage.ErrURLNotFound
+	}
+
+	var tags []string
+	link, err := scanURL(tx.QueryRowContext(ctx, `
+	UPDATE url SET blocked_at = CASE WHEN NOT $3 THEN NULL ELSE COALESCE(blocked_at, now()) END, block_reason = $4, updated_at = now()
+	WHERE domain = $1 AND alias = $2
+	RETURNING `+urlColumns+`, `+tagsColumn, domain, alias, blocked, reason), pq.Array(&tags))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, err
+	}
+	link.Tags = tags
+
+	if err := enqueueEvent(ctx, tx, storage.EventLinkUpdated, domain, alias); err != nil {
+		return storage.URL{}, err
+	}
+
+	after, err := linkSnapshot(ctx, tx, domain, alias)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: action, Actor: actor, Target: linkTarget(domain, alias), Before: before, After: after}); err != nil {
+		return storage.URL{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.URL{}, err
+	}
+
+	return link, nil
+}
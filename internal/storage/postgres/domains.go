@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+type DomainStorage interface {
+	SaveDomain(ctx context.Context, name string, actor storage.Actor) (storage.Domain, error)
+	ListDomains(ctx context.Context) ([]storage.Domain, error)
+	DeleteDomain(ctx context.Context, name string, actor storage.Actor) error
+}
+
+var _ DomainStorage = (*Storage)(nil) // check if Storage implements DomainStorage interface
+
+// domainPayload builds the JSON snapshot of a domain row kept in the audit log.
+func domainPayload(table string) string {
+	return `json_build_object('name', ` + table + `.name, 'createdAt', ` + table + `.created_at)`
+}
+
+func (s *Storage) SaveDomain(ctx context.Context, name string, actor storage.Actor) (_ storage.Domain, err error) {
+	const fn = "storage.postgres.SaveDomain"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.Domain{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var domain storage.Domain
+	var after []byte
+	err = tx.QueryRowContext(ctx, "INSERT INTO domain(name) VALUES($1) RETURNING name, created_at, "+domainPayload("domain"), name).Scan(&domain.Name, &domain.CreatedAt, &after)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" { // PostgreSQL unique violation error code
+				return storage.Domain{}, fmt.Errorf("%s: duplicate entry - %w", fn, storage.ErrDomainExist)
+			}
+		}
+		return storage.Domain{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditDomainCreate, Actor: actor, Target: domain.Name, After: after}); err != nil {
+		return storage.Domain{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Domain{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return domain, nil
+}
+
+func (s *Storage) ListDomains(ctx context.Context) (_ []storage.Domain, err error) {
+	const fn = "storage.postgres.ListDomains"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT name, created_at FROM domain ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	domains := []storage.Domain{}
+	for rows.Next() {
+		var domain storage.Domain
+		if err := rows.Scan(&domain.Name, &domain.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		domains = append(domains, domain)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return domains, nil
+}
+
+// DeleteDomain removes a domain that no link is served on anymore.
+func (s *Storage) DeleteDomain(ctx context.Context, name string, actor storage.Actor) (err error) {
+	const fn = "storage.postgres.DeleteDomain"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	// the lock waits for links being saved on the domain right now
+	var before []byte
+	err = tx.QueryRowContext(ctx, "SELECT "+domainPayload("domain")+" FROM domain WHERE name = $1 FOR UPDATE", name).Scan(&before)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrDomainNotFound
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var inUse bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM url WHERE domain = $1)", name).Scan(&inUse); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if inUse {
+		return storage.ErrDomainInUse
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM domain WHERE name = $1", name); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditDomainDelete, Actor: actor, Target: name, Before: before}); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DomainExists(ctx context.Context, name string) (_ bool, err error) {
+	const fn = "storage.postgres.DomainExists"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM domain WHERE name = $1)", name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return exists, nil
+}
+
+// checkDomain makes sure a link is only stored on a managed domain. The
+// domain row is locked so it cannot be deleted before the link is committed.
+func checkDomain(ctx context.Context, tx *sql.Tx, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	err := tx.QueryRowContext(ctx, "SELECT name FROM domain WHERE name = $1 FOR SHARE", name).Scan(&name)
+	if err == sql.ErrNoRows {
+		return storage.ErrDomainNotFound
+	}
+	return err
+}
@@ -1,24 +1,73 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 	"url_shortener/internal/config"
 	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
 
 	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type URLStorage interface {
-	SaveURL(urlToSave string, alias string) error
-	GetURL(alias string) (string, error)
-	DeleteURL(alias string) error
+	SaveURL(ctx context.Context, link storage.URL, reuseExisting bool, actor storage.Actor) (storage.URL, error)
+	GetURL(ctx context.Context, domain string, alias string, confirmed bool) (storage.URL, error)
+	ResolvePath(ctx context.Context, domain string, path string) (string, error)
+	BlockURL(ctx context.Context, domain string, alias string, reason string, actor storage.Actor) (storage.URL, error)
+	UnblockURL(ctx context.Context, domain string, alias string, actor storage.Actor) (storage.URL, error)
+	GetURLInfo(ctx context.Context, domain string, alias string) (storage.URL, error)
+	ListURLs(ctx context.Context, filter storage.URLFilter) ([]storage.URL, error)
+	UpdateURL(ctx context.Context, link storage.URL, actor storage.Actor) (storage.URL, error)
+	DeleteURL(ctx context.Context, domain string, alias string, actor storage.Actor) error
+	TagStats(ctx context.Context) ([]storage.TagStats, error)
+	CountVariantClick(ctx context.Context, urlID int64, variant string) error
+	LinkStats(ctx context.Context, domain string, alias string) (storage.LinkStats, error)
+	DomainExists(ctx context.Context, name string) (bool, error)
 }
 
 var _ URLStorage = (*Storage)(nil) // check if Storage implements URLStorage interface
 
 type Storage struct {
-	db *sql.DB
+	db              *sql.DB
+	queryTimeout    time.Duration
+	clickThresholds []int
+}
+
+// withTimeout bounds a storage call, with all the statements of its
+// transaction, by the configured query timeout. A zero timeout only keeps the
+// deadline of ctx.
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// tracerName names the tracer of the storage spans.
+const tracerName = "url_shortener/internal/storage/postgres"
+
+// startSpan starts the client span of a storage call named after fn.
+func startSpan(ctx context.Context, fn string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, fn,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL))
+}
+
+// urlColumns is the column list scanned by scanURL.
+const urlColumns = "id, alias, url, max_visits, visits, created_at, interstitial, expires_at, redirect_type, owner, updated_at, last_visit_at, folder, domain, geo_targets, device_rules, variants, sticky_variants, rules, query_passthrough, utm, prefix, destination_hash, blocked_at, block_reason"
+
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
 func New(cfg *config.Config) (*Storage, error) {
@@ -39,37 +88,256 @@ func New(cfg *config.Config) (*Storage, error) {
 		alias TEXT NOT NULL UNIQUE,
 		url TEXT NOT NULL
 	);
-	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);`)
+	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS max_visits INTEGER CHECK (max_visits > 0);
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS visits INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS interstitial BOOLEAN NOT NULL DEFAULT false;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS redirect_type SMALLINT NOT NULL DEFAULT 302;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS last_visit_at TIMESTAMPTZ;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS folder TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_url_folder ON url(folder text_pattern_ops);
+	CREATE TABLE IF NOT EXISTS tag(
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE IF NOT EXISTS url_tag(
+		url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+		tag_id INTEGER NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+		PRIMARY KEY (url_id, tag_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_url_tag_tag ON url_tag(tag_id);
+	CREATE TABLE IF NOT EXISTS domain(
+		name TEXT PRIMARY KEY,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS domain TEXT NOT NULL DEFAULT '';
+	ALTER TABLE url DROP CONSTRAINT IF EXISTS url_alias_key;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_url_domain_alias ON url(domain, alias);
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS expiry_notified BOOLEAN NOT NULL DEFAULT false;
+	CREATE TABLE IF NOT EXISTS webhook_subscription(
+		id BIGSERIAL PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT[] NOT NULL DEFAULT '{}',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE IF NOT EXISTS webhook_outbox(
+		id BIGSERIAL PRIMARY KEY,
+		event TEXT NOT NULL,
+		link JSONB NOT NULL,
+		threshold INTEGER,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		dispatched_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON webhook_outbox(id) WHERE dispatched_at IS NULL;
+	CREATE TABLE IF NOT EXISTS webhook_delivery(
+		id BIGSERIAL PRIMARY KEY,
+		subscription_id BIGINT NOT NULL REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+		event_id BIGINT NOT NULL REFERENCES webhook_outbox(id),
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_status_code INTEGER,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		delivered_at TIMESTAMPTZ,
+		UNIQUE (subscription_id, event_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_delivery(next_attempt_at) WHERE status = 'pending';
+	CREATE TABLE IF NOT EXISTS audit_log(
+		id BIGSERIAL PRIMARY KEY,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		client_ip TEXT NOT NULL DEFAULT '',
+		target TEXT NOT NULL,
+		old_value JSONB,
+		new_value JSONB,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+	CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+	DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+	CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS geo_targets JSONB NOT NULL DEFAULT '{}';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS device_rules JSONB NOT NULL DEFAULT '[]';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '[]';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS sticky_variants BOOLEAN NOT NULL DEFAULT false;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS rules JSONB NOT NULL DEFAULT '[]';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS query_passthrough TEXT NOT NULL DEFAULT '';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS utm JSONB NOT NULL DEFAULT '{}';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS prefix BOOLEAN NOT NULL DEFAULT false;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS destination_hash TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_url_destination_hash ON url(owner, domain, destination_hash) WHERE destination_hash <> '';
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMPTZ;
+	ALTER TABLE url ADD COLUMN IF NOT EXISTS block_reason TEXT NOT NULL DEFAULT '';
+	CREATE TABLE IF NOT EXISTS url_variant_click(
+		url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+		variant TEXT NOT NULL,
+		clicks BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (url_id, variant)
+	);`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, queryTimeout: cfg.QueryTimeout, clickThresholds: cfg.Webhooks.ClickThresholds}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) error {
+// SaveURL creates the link. With reuseExisting, a link with a destination hash
+// is not created when the owner already has a reusable link for it on the
+// domain, which is returned with Reused set instead. The lookup and the insert
+// hold a lock on the owner, domain and hash so concurrent requests for the
+// same destination end up with one link.
+func (s *Storage) SaveURL(ctx context.Context, link storage.URL, reuseExisting bool, actor storage.Actor) (_ storage.URL, err error) {
 	const fn = "storage.postgres.SaveURL"
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES($1, $2)")
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("%s: %w", fn, err)
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkDomain(ctx, tx, link.Domain); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	_, err = stmt.Exec(urlToSave, alias)
-	if pqErr, ok := err.(*pq.Error); ok {
-		if pqErr.Code == "23505" { // PostgreSQL unique violation error code
-			return fmt.Errorf("%s: duplicate entry - %w", fn, storage.ErrURLExist)
+	if reuseExisting && link.DestinationHash != "" {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1 || '/' || $2 || '/' || $3, 0))`,
+			link.Owner, link.Domain, link.DestinationHash); err != nil {
+			return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		existing, err := findByDestination(ctx, tx, link.Domain, link.Owner, link.DestinationHash)
+		if err == nil {
+			existing.Reused = true
+			return existing, nil
+		}
+		if !errors.Is(err, storage.ErrURLNotFound) {
+			return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
 		}
 	}
 
-	return nil
+	saved, err := scanURL(tx.QueryRowContext(ctx, `
+	INSERT INTO url(url, alias, max_visits, interstitial, expires_at, redirect_type, owner, folder, domain, geo_targets, device_rules, variants, sticky_variants, rules,
+		query_passthrough, utm, prefix, destination_hash)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	RETURNING `+urlColumns, link.URL, link.Alias, link.MaxVisits, link.Interstitial, link.ExpiresAt, link.RedirectType, link.Owner, link.Folder, link.Domain,
+		stringMap(link.GeoTargets), jsonArray[storage.DeviceRule](link.DeviceRules), jsonArray[storage.Variant](link.Variants), link.StickyVariants,
+		jsonArray[storage.RedirectRule](link.Rules), link.QueryPassthrough, stringMap(link.UTM), link.Prefix, link.DestinationHash))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" { // PostgreSQL unique violation error code
+				return storage.URL{}, fmt.Errorf("%s: duplicate entry - %w", fn, storage.ErrURLExist)
+			}
+		}
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := setTags(ctx, tx, saved.ID, link.Tags); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	saved.Tags = link.Tags
+
+	if err := enqueueEvent(ctx, tx, storage.EventLinkCreated, saved.Domain, saved.Alias); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	after, err := linkSnapshot(ctx, tx, saved.Domain, saved.Alias)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditLinkCreate, Actor: actor, Target: linkTarget(saved.Domain, saved.Alias), After: after}); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return saved, nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
+// GetURL returns the link of the alias and counts the visit. The visit
+// is counted in the same statement that checks the limit, so concurrent
+// redirects can never exceed max_visits. Links with an interstitial are only
+// resolved once the visitor has confirmed the warning page. Blocked links are
+// never resolved. A visit reaching one of the click thresholds records a
+// link.threshold event.
+func (s *Storage) GetURL(ctx context.Context, domain string, alias string, confirmed bool) (_ storage.URL, err error) {
 	const fn = "storage.postgres.GetURL"
 
-	var url string
-	err := s.db.QueryRow("SELECT url FROM url WHERE alias = $1", alias).Scan(&url)
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// The limit is checked by the WHERE of the UPDATE itself, not by a read
+	// before it. Concurrent redirects of the alias queue on the row lock of
+	// the UPDATE and, under READ COMMITTED, Postgres evaluates the WHERE again
+	// on the row the previous redirect committed. With one visit left, the
+	// first redirect takes it and the others see visits = max_visits, match
+	// no row and get ErrURLExhausted.
+	link, err := scanURL(s.db.QueryRowContext(ctx, `
+	WITH visited AS (
+		UPDATE url SET visits = visits + 1, last_visit_at = now()
+		WHERE domain = $1 AND alias = $2
+			AND (max_visits IS NULL OR visits < max_visits)
+			AND (expires_at IS NULL OR expires_at > now())
+			AND (NOT interstitial OR $3)
+			AND blocked_at IS NULL
+		RETURNING *
+	), threshold AS (
+		INSERT INTO webhook_outbox(event, link, threshold)
+		SELECT $4, `+linkPayload("visited")+`, visits FROM visited WHERE visits = ANY($5::int[])
+	)
+	SELECT `+urlColumns+` FROM visited`, domain, alias, confirmed, storage.EventLinkThreshold, pq.Array(s.clickThresholds)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, s.missingURLError(ctx, fn, domain, alias)
+		}
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return link, nil
+}
+
+// ResolvePath returns the alias of the link serving path: the link with path
+// as its alias or else the prefix link with the longest alias path starts
+// with.
+func (s *Storage) ResolvePath(ctx context.Context, domain string, path string) (_ string, err error) {
+	const fn = "storage.postgres.ResolvePath"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// "docs/v2/intro" can be served by "docs/v2/intro", "docs/v2" or "docs"
+	candidates := []string{path}
+	for i := strings.LastIndex(path, "/"); i > 0; i = strings.LastIndex(path[:i], "/") {
+		candidates = append(candidates, path[:i])
+	}
+
+	var alias string
+	err = s.db.QueryRowContext(ctx, `
+	SELECT alias FROM url
+	WHERE domain = $1 AND alias = ANY($2) AND (prefix OR alias = $3)
+	ORDER BY length(alias) DESC
+	LIMIT 1`, domain, pq.Array(candidates), path).Scan(&alias)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", storage.ErrURLNotFound
@@ -77,16 +345,276 @@ func (s *Storage) GetURL(alias string) (string, error) {
 		return "", fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return url, nil
+	return alias, nil
+}
+
+func (s *Storage) GetURLInfo(ctx context.Context, domain string, alias string) (_ storage.URL, err error) {
+	const fn = "storage.postgres.GetURLInfo"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var tags []string
+
+	link, err := scanURL(s.db.QueryRowContext(ctx, "SELECT "+urlColumns+", "+tagsColumn+" FROM url WHERE domain = $1 AND alias = $2", domain, alias), pq.Array(&tags))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	link.Tags = tags
+
+	return link, nil
+}
+
+// findByDestination returns the oldest link of the owner with the destination
+// hash that can still be visited and is not blocked.
+func findByDestination(ctx context.Context, tx *sql.Tx, domain string, owner string, hash string) (storage.URL, error) {
+	var tags []string
+
+	link, err := scanURL(tx.QueryRowContext(ctx, `
+	SELECT `+urlColumns+`, `+tagsColumn+` FROM url
+	WHERE owner = $1 AND domain = $2 AND destination_hash = $3 AND destination_hash <> '' AND blocked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > now()) AND (max_visits IS NULL OR visits < max_visits)
+	ORDER BY id
+	LIMIT 1`, owner, domain, hash), pq.Array(&tags))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, err
+	}
+	link.Tags = tags
+
+	return link, nil
+}
+
+// UpdateURL replaces the destination and settings of an existing alias,
+// keeping its visits and creation time. The destination hash is only kept
+// for links with a generated alias that never had other settings.
+func (s *Storage) UpdateURL(ctx context.Context, link storage.URL, actor storage.Actor) (_ storage.URL, err error) {
+	const fn = "storage.postgres.UpdateURL"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	before, err := linkSnapshot(ctx, tx, link.Domain, link.Alias)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if before == nil {
+		return storage.URL{}, storage.ErrURLNotFound
+	}
+
+	updated, err := scanURL(tx.QueryRowContext(ctx, `
+	UPDATE url SET url = $3, max_visits = $4, interstitial = $5, expires_at = $6, redirect_type = $7, folder = $8, updated_at = now(),
+		expiry_notified = false, geo_targets = $9, device_rules = $10, variants = $11, sticky_variants = $12, rules = $13,
+		query_passthrough = $14, utm = $15, prefix = $16,
+		destination_hash = CASE WHEN destination_hash = '' THEN '' ELSE $17 END
+	WHERE domain = $1 AND alias = $2
+	RETURNING `+urlColumns, link.Domain, link.Alias, link.URL, link.MaxVisits, link.Interstitial, link.ExpiresAt, link.RedirectType, link.Folder,
+		stringMap(link.GeoTargets), jsonArray[storage.DeviceRule](link.DeviceRules), jsonArray[storage.Variant](link.Variants), link.StickyVariants,
+		jsonArray[storage.RedirectRule](link.Rules), link.QueryPassthrough, stringMap(link.UTM), link.Prefix, link.DestinationHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := setTags(ctx, tx, updated.ID, link.Tags); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	updated.Tags = link.Tags
+
+	if err := enqueueEvent(ctx, tx, storage.EventLinkUpdated, updated.Domain, updated.Alias); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	after, err := linkSnapshot(ctx, tx, updated.Domain, updated.Alias)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditLinkUpdate, Actor: actor, Target: linkTarget(updated.Domain, updated.Alias), Before: before, After: after}); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return updated, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error {
+func (s *Storage) DeleteURL(ctx context.Context, domain string, alias string, actor storage.Actor) (err error) {
 	const fn = "storage.postgres.DeleteURL"
 
-	_, err := s.db.Exec(`DELETE FROM url WHERE alias = $1`, alias)
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	before, err := linkSnapshot(ctx, tx, domain, alias)
 	if err != nil {
 		return fmt.Errorf("%s: %w", fn, err)
 	}
+	if before == nil {
+		return nil
+	}
+
+	// the event is recorded first, while the link can still be read
+	if err := enqueueEvent(ctx, tx, storage.EventLinkDeleted, domain, alias); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM url WHERE domain = $1 AND alias = $2`, domain, alias); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditLinkDelete, Actor: actor, Target: linkTarget(domain, alias), Before: before}); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
 
+// missingURLError tells apart an alias that does not exist from one that has
+// used up its visits limit, has expired or is waiting for the interstitial to
+// be confirmed.
+func (s *Storage) missingURLError(ctx context.Context, fn string, domain string, alias string) error {
+	var blocked, exhausted, expired bool
+	err := s.db.QueryRowContext(ctx, `
+	SELECT blocked_at IS NOT NULL, max_visits IS NOT NULL AND visits >= max_visits, expires_at IS NOT NULL AND expires_at <= now()
+	FROM url WHERE domain = $1 AND alias = $2`, domain, alias).Scan(&blocked, &exhausted, &expired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrURLNotFound
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if blocked {
+		return storage.ErrURLBlocked
+	}
+	if exhausted {
+		return storage.ErrURLExhausted
+	}
+	if expired {
+		return storage.ErrURLExpired
+	}
+	return storage.ErrURLNeedsConfirmation
+}
+
+// scanURL reads a row selected with urlColumns. Columns selected after them
+// are scanned into extra.
+func scanURL(row rowScanner, extra ...any) (storage.URL, error) {
+	var link storage.URL
+	var maxVisits sql.NullInt32
+	var expiresAt, updatedAt, lastVisitAt, blockedAt sql.NullTime
+
+	dest := []any{&link.ID, &link.Alias, &link.URL, &maxVisits, &link.Visits, &link.CreatedAt, &link.Interstitial, &expiresAt, &link.RedirectType,
+		&link.Owner, &updatedAt, &lastVisitAt, &link.Folder, &link.Domain, (*stringMap)(&link.GeoTargets),
+		(*jsonArray[storage.DeviceRule])(&link.DeviceRules), (*jsonArray[storage.Variant])(&link.Variants), &link.StickyVariants,
+		(*jsonArray[storage.RedirectRule])(&link.Rules), &link.QueryPassthrough, (*stringMap)(&link.UTM),
+		&link.Prefix, &link.DestinationHash, &blockedAt, &link.BlockReason}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return storage.URL{}, err
+	}
+
+	if maxVisits.Valid {
+		v := int(maxVisits.Int32)
+		link.MaxVisits = &v
+	}
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if updatedAt.Valid {
+		link.UpdatedAt = &updatedAt.Time
+	}
+	if lastVisitAt.Valid {
+		link.LastVisitAt = &lastVisitAt.Time
+	}
+	if blockedAt.Valid {
+		link.BlockedAt = &blockedAt.Time
+	}
+
+	return link, nil
+}
+
+// stringMap keeps a map of strings in a JSONB object column.
+type stringMap map[string]string
+
+func (m stringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	doc, err := json.Marshal(map[string]string(m))
+	return string(doc), err
+}
+
+func (m *stringMap) Scan(src any) error {
+	doc, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a string map", src)
+	}
+
+	var scanned map[string]string
+	if err := json.Unmarshal(doc, &scanned); err != nil {
+		return err
+	}
+	if len(scanned) == 0 {
+		scanned = nil
+	}
+	*m = scanned
+	return nil
+}
+
+// jsonArray keeps a slice in a JSONB array column.
+type jsonArray[T any] []T
+
+func (a jsonArray[T]) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	doc, err := json.Marshal([]T(a))
+	return string(doc), err
+}
+
+func (a *jsonArray[T]) Scan(src any) error {
+	doc, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a json array", src)
+	}
+
+	var scanned []T
+	if err := json.Unmarshal(doc, &scanned); err != nil {
+		return err
+	}
+	if len(scanned) == 0 {
+		scanned = nil
+	}
+	*a = scanned
 	return nil
 }
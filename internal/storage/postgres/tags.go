@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// tagsColumn selects the sorted tag names of the url row as a text array.
+const tagsColumn = `COALESCE((
+	SELECT array_agg(t.name ORDER BY t.name)
+	FROM url_tag ut JOIN tag t ON t.id = ut.tag_id
+	WHERE ut.url_id = url.id), '{}')`
+
+// setTags replaces the tags of the link, creating the tags that do not exist yet.
+func setTags(ctx context.Context, tx *sql.Tx, urlID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM url_tag WHERE url_id = $1", urlID); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO tag(name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", pq.Array(tags)); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO url_tag(url_id, tag_id)
+	SELECT $1, id FROM tag WHERE name = ANY($2)`, urlID, pq.Array(tags))
+	return err
+}
+
+func (s *Storage) ListURLs(ctx context.Context, filter storage.URLFilter) (_ []storage.URL, err error) {
+	const fn = "storage.postgres.ListURLs"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if !filter.AllDomains {
+		where = append(where, "domain = "+arg(filter.Domain))
+	}
+	if filter.Tag != "" {
+		where = append(where, `EXISTS (
+		SELECT 1 FROM url_tag ut JOIN tag t ON t.id = ut.tag_id
+		WHERE ut.url_id = url.id AND t.name = `+arg(filter.Tag)+`)`)
+	}
+	if filter.Folder != "" {
+		where = append(where, "(folder = "+arg(filter.Folder)+" OR folder LIKE "+arg(subfolderPattern(filter.Folder))+` ESCAPE '\')`)
+	}
+
+	if filter.AfterID != nil {
+		where = append(where, "id > "+arg(*filter.AfterID))
+	}
+
+	query := "SELECT " + urlColumns + ", " + tagsColumn + " FROM url"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if filter.AfterID != nil {
+		query += " ORDER BY id LIMIT " + arg(filter.Limit)
+	} else {
+		query += " ORDER BY created_at DESC, id DESC LIMIT " + arg(filter.Limit) + " OFFSET " + arg(filter.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	links := []storage.URL{}
+	for rows.Next() {
+		var tags []string
+		link, err := scanURL(rows, pq.Array(&tags))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		link.Tags = tags
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return links, nil
+}
+
+// subfolderPattern is the LIKE pattern of the folders below folder, with the
+// wildcards folder names may contain matched literally.
+func subfolderPattern(folder string) string {
+	return likeEscaper.Replace(folder) + "/%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (s *Storage) TagStats(ctx context.Context) (_ []storage.TagStats, err error) {
+	const fn = "storage.postgres.TagStats"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT t.name, count(u.id), COALESCE(sum(u.visits), 0)
+	FROM tag t
+	JOIN url_tag ut ON ut.tag_id = t.id
+	JOIN url u ON u.id = ut.url_id
+	GROUP BY t.name
+	ORDER BY t.name`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	stats := []storage.TagStats{}
+	for rows.Next() {
+		var st storage.TagStats
+		if err := rows.Scan(&st.Tag, &st.Links, &st.Visits); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return stats, nil
+}
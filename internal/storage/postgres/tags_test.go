@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubfolderPattern(t *testing.T) {
+	tests := []struct {
+		folder   string
+		expected string
+	}{
+		{folder: "team/campaign", expected: "team/campaign/%"},
+		{folder: "a_b", expected: `a\_b/%`},
+		{folder: "100%", expected: `100\%/%`},
+		{folder: `back\slash`, expected: `back\\slash/%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.folder, func(t *testing.T) {
+			assert.Equal(t, tt.expected, subfolderPattern(tt.folder))
+		})
+	}
+}
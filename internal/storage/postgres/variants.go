@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// CountVariantClick adds a visit served by the variant of the link.
+func (s *Storage) CountVariantClick(ctx context.Context, urlID int64, variant string) (err error) {
+	const fn = "storage.postgres.CountVariantClick"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO url_variant_click(url_id, variant, clicks) VALUES($1, $2, 1)
+	ON CONFLICT (url_id, variant) DO UPDATE SET clicks = url_variant_click.clicks + 1`, urlID, variant)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// LinkStats returns the link with the visits served by each of its variants.
+// Variants that were removed from the link are left out.
+func (s *Storage) LinkStats(ctx context.Context, domain string, alias string) (_ storage.LinkStats, err error) {
+	const fn = "storage.postgres.LinkStats"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var tags []string
+	link, err := scanURL(s.db.QueryRowContext(ctx, "SELECT "+urlColumns+", "+tagsColumn+" FROM url WHERE domain = $1 AND alias = $2", domain, alias), pq.Array(&tags))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.LinkStats{}, storage.ErrURLNotFound
+		}
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	link.Tags = tags
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT c.variant, c.clicks FROM url_variant_click c JOIN url u ON u.id = c.url_id
+	WHERE c.url_id = $1 AND c.variant IN (SELECT v->>'name' FROM jsonb_array_elements(u.variants) v)`, link.ID)
+	if err != nil {
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	stats := storage.LinkStats{Link: link, VariantClicks: map[string]int{}}
+	for rows.Next() {
+		var variant string
+		var clicks int
+		if err := rows.Scan(&variant, &clicks); err != nil {
+			return storage.LinkStats{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		stats.VariantClicks[variant] = clicks
+	}
+	if err := rows.Err(); err != nil {
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return stats, nil
+}
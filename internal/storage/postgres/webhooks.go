@@ -0,0 +1,389 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+type WebhookStorage interface {
+	SaveSubscription(ctx context.Context, sub storage.Subscription, actor storage.Actor) (storage.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]storage.Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64, actor storage.Actor) error
+	ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]storage.Delivery, error)
+	EnqueueExpired(ctx context.Context) (int64, error)
+	DispatchEvents(ctx context.Context) (int64, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]storage.PendingDelivery, error)
+	RecordAttempt(ctx context.Context, attempt storage.DeliveryAttempt) error
+}
+
+var _ WebhookStorage = (*Storage)(nil) // check if Storage implements WebhookStorage interface
+
+// linkPayload builds the JSON snapshot of a url row that is sent with its
+// events. table is the name the url row is selected under.
+func linkPayload(table string) string {
+	return `json_build_object(
+		'alias', ` + table + `.alias,
+		'domain', ` + table + `.domain,
+		'url', ` + table + `.url,
+		'owner', ` + table + `.owner,
+		'visits', ` + table + `.visits,
+		'maxVisits', ` + table + `.max_visits,
+		'expiresAt', ` + table + `.expires_at,
+		'redirectType', ` + table + `.redirect_type,
+		'folder', ` + table + `.folder,
+		'geoTargets', ` + table + `.geo_targets,
+		'deviceRules', ` + table + `.device_rules,
+		'variants', ` + table + `.variants,
+		'stickyVariants', ` + table + `.sticky_variants,
+		'rules', ` + table + `.rules,
+		'queryPassthrough', ` + table + `.query_passthrough,
+		'utm', ` + table + `.utm,
+		'prefix', ` + table + `.prefix,
+		'blockedAt', ` + table + `.blocked_at,
+		'blockReason', ` + table + `.block_reason,
+		'tags', COALESCE((
+			SELECT array_agg(t.name ORDER BY t.name)
+			FROM url_tag ut JOIN tag t ON t.id = ut.tag_id
+			WHERE ut.url_id = ` + table + `.id), '{}'),
+		'createdAt', ` + table + `.created_at,
+		'updatedAt', ` + table + `.updated_at)`
+}
+
+// enqueueEvent records an event of the link in the outbox. It runs in the
+// transaction of the change, so the event is only sent if the change commits.
+func enqueueEvent(ctx context.Context, tx *sql.Tx, event string, domain string, alias string) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO webhook_outbox(event, link)
+	SELECT $1, `+linkPayload("url")+` FROM url WHERE domain = $2 AND alias = $3`, event, domain, alias)
+	return err
+}
+
+// subscriptionPayload builds the JSON snapshot of a subscription row kept in
+// the audit log. The secret is left out.
+func subscriptionPayload(table string) string {
+	return `json_build_object('id', ` + table + `.id, 'url', ` + table + `.url, 'events', ` + table + `.events, 'createdAt', ` + table + `.created_at)`
+}
+
+func (s *Storage) SaveSubscription(ctx context.Context, sub storage.Subscription, actor storage.Actor) (_ storage.Subscription, err error) {
+	const fn = "storage.postgres.SaveSubscription"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.Subscription{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var after []byte
+	err = tx.QueryRowContext(ctx, "INSERT INTO webhook_subscription(url, secret, events) VALUES($1, $2, $3) RETURNING id, created_at, "+subscriptionPayload("webhook_subscription"),
+		sub.URL, sub.Secret, pq.Array(sub.Events)).Scan(&sub.ID, &sub.CreatedAt, &after)
+	if err != nil {
+		return storage.Subscription{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditWebhookCreate, Actor: actor, Target: strconv.FormatInt(sub.ID, 10), After: after}); err != nil {
+		return storage.Subscription{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Subscription{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return sub, nil
+}
+
+func (s *Storage) ListSubscriptions(ctx context.Context) (_ []storage.Subscription, err error) {
+	const fn = "storage.postgres.ListSubscriptions"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, secret, events, created_at FROM webhook_subscription ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	subs := []storage.Subscription{}
+	for rows.Next() {
+		var sub storage.Subscription
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&sub.Events), &sub.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return subs, nil
+}
+
+func (s *Storage) DeleteSubscription(ctx context.Context, id int64, actor storage.Actor) (err error) {
+	const fn = "storage.postgres.DeleteSubscription"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var before []byte
+	err = tx.QueryRowContext(ctx, "DELETE FROM webhook_subscription WHERE id = $1 RETURNING "+subscriptionPayload("webhook_subscription"), id).Scan(&before)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrSubscriptionNotFound
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := recordAudit(ctx, tx, storage.AuditEntry{Action: storage.AuditWebhookDelete, Actor: actor, Target: strconv.FormatInt(id, 10), Before: before}); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// deliveryColumns is the column list scanned by scanDelivery, with d being the
+// webhook_delivery row and o the webhook_outbox row of its event.
+func deliveryColumns(d string, o string) string {
+	return d + ".id, " + d + ".subscription_id, " + d + ".status, " + d + ".attempts, " + d + ".next_attempt_at, " +
+		d + ".last_status_code, " + d + ".last_error, " + d + ".created_at, " + d + ".delivered_at, " +
+		o + ".id, " + o + ".event, " + o + ".link, " + o + ".threshold, " + o + ".created_at"
+}
+
+func scanDelivery(row rowScanner, extra ...any) (storage.Delivery, error) {
+	var d storage.Delivery
+	var statusCode, threshold sql.NullInt32
+	var deliveredAt sql.NullTime
+	var link []byte
+
+	dest := []any{&d.ID, &d.SubscriptionID, &d.Status, &d.Attempts, &d.NextAttemptAt, &statusCode, &d.LastError, &d.CreatedAt, &deliveredAt,
+		&d.Event.ID, &d.Event.Type, &link, &threshold, &d.Event.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return storage.Delivery{}, err
+	}
+	d.Event.Link = link
+
+	if statusCode.Valid {
+		v := int(statusCode.Int32)
+		d.LastStatusCode = &v
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	if threshold.Valid {
+		v := int(threshold.Int32)
+		d.Event.Threshold = &v
+	}
+
+	return d, nil
+}
+
+// ListDeliveries returns the delivery log, newest first.
+func (s *Storage) ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) (_ []storage.Delivery, err error) {
+	const fn = "storage.postgres.ListDeliveries"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.SubscriptionID != 0 {
+		where = append(where, "d.subscription_id = "+arg(filter.SubscriptionID))
+	}
+	if filter.Status != "" {
+		where = append(where, "d.status = "+arg(filter.Status))
+	}
+
+	query := "SELECT " + deliveryColumns("d", "o") + " FROM webhook_delivery d JOIN webhook_outbox o ON o.id = d.event_id"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY d.id DESC LIMIT " + arg(filter.Limit) + " OFFSET " + arg(filter.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	deliveries := []storage.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return deliveries, nil
+}
+
+// EnqueueExpired records a link.expired event for every link that expired
+// since the last call and returns how many there were.
+func (s *Storage) EnqueueExpired(ctx context.Context) (_ int64, err error) {
+	const fn = "storage.postgres.EnqueueExpired"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+	WITH expired AS (
+		UPDATE url SET expiry_notified = true
+		WHERE expires_at <= now() AND NOT expiry_notified
+		RETURNING *
+	)
+	INSERT INTO webhook_outbox(event, link)
+	SELECT $1, `+linkPayload("expired")+` FROM expired`, storage.EventLinkExpired)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	enqueued, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return enqueued, nil
+}
+
+// DispatchEvents turns the new outbox events into a delivery for every
+// subscription interested in them and returns how many deliveries were created.
+func (s *Storage) DispatchEvents(ctx context.Context) (_ int64, err error) {
+	const fn = "storage.postgres.DispatchEvents"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+	WITH events AS (
+		UPDATE webhook_outbox SET dispatched_at = now()
+		WHERE id IN (SELECT id FROM webhook_outbox WHERE dispatched_at IS NULL ORDER BY id FOR UPDATE SKIP LOCKED)
+		RETURNING id, event
+	)
+	INSERT INTO webhook_delivery(subscription_id, event_id)
+	SELECT s.id, e.id FROM events e
+	JOIN webhook_subscription s ON cardinality(s.events) = 0 OR e.event = ANY(s.events)
+	ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	dispatched, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dispatched, nil
+}
+
+// ClaimDeliveries returns up to limit deliveries that are due. They are
+// pushed back by lease, so other workers skip them until the attempt has been
+// recorded or the lease has run out.
+func (s *Storage) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (_ []storage.PendingDelivery, err error) {
+	const fn = "storage.postgres.ClaimDeliveries"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+	WITH claimed AS (
+		UPDATE webhook_delivery SET next_attempt_at = now() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT id FROM webhook_delivery
+			WHERE status = $1 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at LIMIT $2
+			FOR UPDATE SKIP LOCKED)
+		RETURNING *
+	)
+	SELECT `+deliveryColumns("c", "o")+`, s.url, s.secret
+	FROM claimed c
+	JOIN webhook_outbox o ON o.id = c.event_id
+	JOIN webhook_subscription s ON s.id = c.subscription_id
+	ORDER BY c.next_attempt_at, c.id`, storage.DeliveryPending, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	pending := []storage.PendingDelivery{}
+	for rows.Next() {
+		var p storage.PendingDelivery
+		if p.Delivery, err = scanDelivery(rows, &p.URL, &p.Secret); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		pending = append(pending, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return pending, nil
+}
+
+func (s *Storage) RecordAttempt(ctx context.Context, attempt storage.DeliveryAttempt) (err error) {
+	const fn = "storage.postgres.RecordAttempt"
+
+	ctx, span := startSpan(ctx, fn)
+	defer tracing.End(span, &err)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	status := storage.DeliveryPending
+	if attempt.Delivered {
+		status = storage.DeliveryDelivered
+	} else if attempt.NextAttemptAt == nil {
+		status = storage.DeliveryFailed
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+	UPDATE webhook_delivery SET attempts = attempts + 1, status = $2, last_status_code = $3, last_error = $4,
+		next_attempt_at = COALESCE($5, next_attempt_at),
+		delivered_at = CASE WHEN $2 = $6 THEN now() END
+	WHERE id = $1`, attempt.DeliveryID, status, attempt.StatusCode, attempt.Error, attempt.NextAttemptAt, storage.DeliveryDelivered)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"net/url"
+	"time"
+)
+
+// URL is a short link record as it is kept in the storage.
+type URL struct {
+	ID        int64
+	Alias     string
+	URL       string
+	MaxVisits *int // nil means the link can be visited any number of times
+	Visits    int
+	CreatedAt time.Time
+	// Interstitial makes the redirect show a warning page first.
+	Interstitial bool
+	ExpiresAt    *time.Time // nil means the link never expires
+	RedirectType int        // HTTP status used for the redirect
+	Owner        string     // user that created the link
+	UpdatedAt    *time.Time
+	LastVisitAt  *time.Time
+	Tags         []string
+	Folder       string // slash separated path, empty for the root folder
+	Domain       string // hostname the link is served on, empty for the default domain
+	// GeoTargets maps ISO 3166-1 alpha-2 country codes to the destinations
+	// that replace URL for visitors from those countries.
+	GeoTargets map[string]string
+	// Rules send matching visits elsewhere, the first matching rule wins and
+	// takes precedence over all other targeting.
+	Rules []RedirectRule
+	// DeviceRules send matching visitors elsewhere, the first matching rule
+	// wins and takes precedence over GeoTargets.
+	DeviceRules []DeviceRule
+	// Variants split the visits no targeting applies to between weighted
+	// destinations that replace URL.
+	Variants []Variant
+	// StickyVariants serves returning visitors the variant they got first.
+	StickyVariants bool
+	// Variant is the name of the variant GetURL served, empty if none was.
+	Variant string
+	// QueryPassthrough is how the query of the short link is forwarded to
+	// the destination, one of the Query policies.
+	QueryPassthrough string
+	// UTM are utm_* parameters added to the destination unless it already
+	// has them.
+	UTM map[string]string
+	// Prefix forwards the rest of the path after the alias to the
+	// destination, "docs/intro" goes to the destination of "docs" + "/intro".
+	Prefix bool
+	// DestinationHash identifies the normalized destination of links with a
+	// generated alias and default settings, which are reused for the same
+	// destination on request. It is empty for other links.
+	DestinationHash string
+	// Reused is set when SaveURL returned an existing link for the
+	// destination instead of saving a new one.
+	Reused bool
+	// BlockedAt is when the link was blocked, nil if it was not. A blocked
+	// link serves a warning page instead of redirecting.
+	BlockedAt   *time.Time
+	BlockReason string
+}
+
+// Query policies of a link. QueryDrop drops the query of the short link,
+// QueryMerge adds the parameters the destination does not have and
+// QueryOverride also replaces those it has.
+const (
+	QueryDrop     = ""
+	QueryMerge    = "merge"
+	QueryOverride = "override"
+)
+
+// DeviceRule matches visitors on their parsed User-Agent. Empty conditions
+// match any visitor, but a rule has at least one.
+type DeviceRule struct {
+	OS     string `json:"os,omitempty"`     // ios, android, windows, macos, linux or chromeos
+	Device string `json:"device,omitempty"` // mobile, tablet, desktop or bot
+	URL    string `json:"url"`
+}
+
+// RedirectRule matches a visit on when it happens, the language of the
+// visitor and the query of the short link. Empty conditions match any visit,
+// but a rule has at least one.
+type RedirectRule struct {
+	// Days are the weekdays the rule applies on, "mon" to "sun".
+	Days []string `json:"days,omitempty"`
+	// From and To bound the time of day as "15:04", To excluded. A window
+	// ending before it starts spans midnight.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Timezone is the IANA zone Days, From and To are in, UTC when empty.
+	Timezone string     `json:"timezone,omitempty"`
+	After    *time.Time `json:"after,omitempty"`
+	Before   *time.Time `json:"before,omitempty"`
+	// Languages match the preferred language of the visitor, "de" matches
+	// "de" and "de-at".
+	Languages []string `json:"languages,omitempty"`
+	// Query matches query parameters, an empty value matches any value.
+	Query map[string]string `json:"query,omitempty"`
+	URL   string            `json:"url"`
+}
+
+// Variant is one of the weighted destinations of a split link. Each visit
+// picks a variant with a probability proportional to its weight.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// LinkStats counts the visits of a link and the visits served by each of
+// its variants.
+type LinkStats struct {
+	Link          URL
+	VariantClicks map[string]int
+}
+
+// URLFilter narrows down a listing of links.
+type URLFilter struct {
+	// Domain selects the links of one domain, "" being the default domain,
+	// unless AllDomains is set.
+	Domain     string
+	AllDomains bool
+	Tag        string
+	// Folder matches the folder itself and all of its subfolders.
+	Folder string
+	Limit  int
+	Offset int
+	// AfterID, when set, lists the links with a greater id in id order
+	// instead of the newest first, so that walking all links batch by batch
+	// is not thrown off by links added or deleted meanwhile.
+	AfterID *int64
+}
+
+// TagStats aggregates the links carrying a tag.
+type TagStats struct {
+	Tag    string
+	Links  int
+	Visits int
+}
+
+// Remaining returns how many visits are left before the link is exhausted
+// or nil if the link has no visits limit.
+func (u URL) Remaining() *int {
+	if u.MaxVisits == nil {
+		return nil
+	}
+
+	remaining := max(*u.MaxVisits-u.Visits, 0)
+	return &remaining
+}
+
+// Gone reports whether the link can no longer be visited at now, because it
+// ran out of visits or expired.
+func (u URL) Gone(now time.Time) bool {
+	if remaining := u.Remaining(); remaining != nil && *remaining == 0 {
+		return true
+	}
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(now)
+}
+
+// ShortURL is the public link that redirects to the alias, with base being
+// the URL aliases are appended to. Links on a custom domain keep the scheme
+// and path of the base.
+func (u URL) ShortURL(base url.URL) string {
+	if u.Domain != "" {
+		base.Host = u.Domain
+	}
+	return base.String() + "/" + u.Alias
+}
+
+// Destinations returns every URL the link can redirect to: its default
+// destination and those of its targeting rules and variants.
+func (u URL) Destinations() []string {
+	destinations := []string{u.URL}
+	for _, target := range u.GeoTargets {
+		destinations = append(destinations, target)
+	}
+	for _, rule := range u.Rules {
+		destinations = append(destinations, rule.URL)
+	}
+	for _, rule := range u.DeviceRules {
+		destinations = append(destinations, rule.URL)
+	}
+	for _, variant := range u.Variants {
+		destinations = append(destinations, variant.URL)
+	}
+	return destinations
+}
+
+// Dynamic reports whether visits of the link can be sent to another
+// destination or be refused later on, so its redirects must not be cached.
+func (u URL) Dynamic() bool {
+	return u.MaxVisits != nil || u.ExpiresAt != nil || len(u.GeoTargets) > 0 ||
+		len(u.Rules) > 0 || len(u.DeviceRules) > 0 || len(u.Variants) > 0
+}
+
+// Domain is a branded hostname links can be served on.
+type Domain struct {
+	Name      string
+	CreatedAt time.Time
+}
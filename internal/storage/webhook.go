@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Link lifecycle events delivered to webhook subscriptions.
+const (
+	EventLinkCreated   = "link.created"
+	EventLinkUpdated   = "link.updated"
+	EventLinkDeleted   = "link.deleted"
+	EventLinkExpired   = "link.expired"
+	EventLinkThreshold = "link.threshold" // the visits of a link reached a click threshold
+)
+
+// WebhookEvents lists every event a subscription can filter on.
+var WebhookEvents = []string{EventLinkCreated, EventLinkUpdated, EventLinkDeleted, EventLinkExpired, EventLinkThreshold}
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // gave up after the last retry
+)
+
+// Subscription is an endpoint receiving link events.
+type Subscription struct {
+	ID     int64
+	URL    string
+	Secret string // key of the HMAC signature of the payloads
+	// Events the endpoint receives, empty for all of them.
+	Events    []string
+	CreatedAt time.Time
+}
+
+// Event is a link change recorded in the outbox.
+type Event struct {
+	ID        int64
+	Type      string
+	Link      json.RawMessage // the link as it was when the event happened
+	Threshold *int            // visits reached by a link.threshold event
+	CreatedAt time.Time
+}
+
+// Delivery tracks sending one event to one subscription.
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	Event          Event
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int // nil if the endpoint could not be reached
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// PendingDelivery is a delivery claimed by the worker together with the
+// endpoint it goes to.
+type PendingDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// DeliveryFilter narrows down the delivery log.
+type DeliveryFilter struct {
+	SubscriptionID int64
+	Status         string
+	Limit          int
+	Offset         int
+}
+
+// DeliveryAttempt is the outcome of sending a delivery. A failed attempt
+// without NextAttemptAt gives up on the delivery.
+type DeliveryAttempt struct {
+	DeliveryID    int64
+	StatusCode    *int
+	Error         string
+	Delivered     bool
+	NextAttemptAt *time.Time
+}
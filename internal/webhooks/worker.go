@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/postgres"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret, prefixed by
+// "sha256=", so receivers can reject forged and replayed payloads.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxErrorLength caps the response body kept in the delivery log.
+const maxErrorLength = 512
+
+// Payload is the JSON body posted to the subscriptions.
+type Payload struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Link      json.RawMessage `json:"link"`
+	Threshold *int            `json:"threshold,omitempty"`
+}
+
+// Worker delivers the link events recorded in the outbox.
+type Worker struct {
+	storage postgres.WebhookStorage
+	client  *http.Client
+	cfg     config.Webhooks
+	now     func() time.Time
+	log     *slog.Logger
+}
+
+func NewWorker(storage postgres.WebhookStorage, cfg config.Webhooks, logger *slog.Logger) *Worker {
+	return &Worker{storage: storage, client: &http.Client{Timeout: cfg.Timeout}, cfg: cfg, now: time.Now, log: logger}
+}
+
+// Run delivers events every configured interval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick records the expired links, fans the new events out to the
+// subscriptions and sends the deliveries that are due.
+func (w *Worker) Tick(ctx context.Context) {
+	const fn = "webhooks.worker.Tick"
+	log := w.log.With(
+		slog.String("fn", fn),
+	)
+
+	if _, err := w.storage.EnqueueExpired(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to enqueue expired links", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+	}
+
+	if _, err := w.storage.DispatchEvents(ctx); err != nil {
+		log.ErrorContext(ctx, "failed to dispatch events", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return
+	}
+
+	// the lease keeps other workers off the deliveries while they are sent
+	lease := time.Duration(w.cfg.BatchSize+1) * w.cfg.Timeout
+	deliveries, err := w.storage.ClaimDeliveries(ctx, w.cfg.BatchSize, lease)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to claim deliveries", slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		return
+	}
+
+	for _, d := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		attempt := w.deliver(ctx, d)
+		// the attempt is recorded even on shutdown, so a delivered event is
+		// not sent again once the lease runs out
+		if err := w.storage.RecordAttempt(context.WithoutCancel(ctx), attempt); err != nil {
+			log.ErrorContext(ctx, "failed to record delivery attempt", slog.Int64("delivery", d.ID), slog.Attr{Key: "error", Value: slog.StringValue(err.Error())})
+		}
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, d storage.PendingDelivery) storage.DeliveryAttempt {
+	const fn = "webhooks.worker.deliver"
+	log := w.log.With(
+		slog.String("fn", fn),
+		slog.Int64("delivery", d.ID),
+	)
+
+	attempt := storage.DeliveryAttempt{DeliveryID: d.ID}
+
+	body, err := json.Marshal(Payload{ID: d.Event.ID, Event: d.Event.Type, CreatedAt: d.Event.CreatedAt, Link: d.Event.Link, Threshold: d.Event.Threshold})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+	} else {
+		defer resp.Body.Close()
+		attempt.StatusCode = &resp.StatusCode
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			attempt.Delivered = true
+			return attempt
+		}
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		attempt.Error = fmt.Sprintf("unexpected status %d: %s", resp.StatusCode, snippet)
+	}
+
+	attempts := d.Attempts + 1
+	if attempts >= w.cfg.MaxAttempts {
+		log.WarnContext(ctx, "giving up on delivery", slog.Int("attempts", attempts), slog.String("error", attempt.Error))
+		return attempt
+	}
+
+	next := w.now().Add(w.backoff(attempts))
+	attempt.NextAttemptAt = &next
+	log.InfoContext(ctx, "delivery failed, retrying later", slog.Int("attempts", attempts), slog.Time("next_attempt_at", next), slog.String("error", attempt.Error))
+	return attempt
+}
+
+// backoff doubles the delay with every failed attempt.
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.cfg.BackoffBase
+	for i := 1; i < attempts && delay < w.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, w.cfg.BackoffMax)
+}
+
+// Sign returns the signature header value of a payload.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
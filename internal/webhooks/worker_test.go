@@ -0,0 +1,164 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url_shortener/internal/config"
+	"url_shortener/internal/storage"
+	"url_shortener/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.Webhooks{
+	Interval:    time.Second,
+	Timeout:     time.Second,
+	BatchSize:   10,
+	MaxAttempts: 3,
+	BackoffBase: 30 * time.Second,
+	BackoffMax:  time.Hour,
+}
+
+func newTestWorker(storage *mocks.WebhookStorage, now time.Time) *Worker {
+	w := NewWorker(storage, testConfig, slog.Default())
+	w.now = func() time.Time { return now }
+	return w
+}
+
+func pendingDelivery(url string, attempts int) storage.PendingDelivery {
+	threshold := 100
+	return storage.PendingDelivery{
+		Delivery: storage.Delivery{
+			ID:       7,
+			Attempts: attempts,
+			Event: storage.Event{
+				ID:        42,
+				Type:      storage.EventLinkThreshold,
+				Link:      json.RawMessage(`{"alias":"test","visits":100}`),
+				Threshold: &threshold,
+				CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		},
+		URL:    url,
+		Secret: "s3cret",
+	}
+}
+
+func TestTickDeliversSignedPayload(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var received *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	mockStorage := new(mocks.WebhookStorage)
+	mockStorage.On("EnqueueExpired", mock.Anything).Return(int64(0), nil)
+	mockStorage.On("DispatchEvents", mock.Anything).Return(int64(1), nil)
+	mockStorage.On("ClaimDeliveries", mock.Anything, 10, mock.Anything).Return([]storage.PendingDelivery{pendingDelivery(receiver.URL, 0)}, nil)
+	mockStorage.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(a storage.DeliveryAttempt) bool {
+		return a.DeliveryID == 7 && a.Delivered && *a.StatusCode == http.StatusNoContent && a.NextAttemptAt == nil
+	})).Return(nil)
+
+	newTestWorker(mockStorage, now).Tick(context.Background())
+
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, storage.EventLinkThreshold, received.Header.Get(HeaderEvent))
+	assert.Equal(t, "7", received.Header.Get(HeaderDelivery))
+	assert.Equal(t, "1735787045", received.Header.Get(HeaderTimestamp))
+	assert.Equal(t, Sign("s3cret", "1735787045", body), received.Header.Get(HeaderSignature))
+	assert.JSONEq(t, `{"id":42,"event":"link.threshold","createdAt":"2025-01-02T03:04:05Z","link":{"alias":"test","visits":100},"threshold":100}`, string(body))
+	mockStorage.AssertExpectations(t)
+}
+
+func TestDeliverRetries(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "temporarily unavailable", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	tests := []struct {
+		name              string
+		url               string
+		attempts          int
+		expectedStatus    *int
+		expectedNextRetry *time.Time
+	}{
+		{
+			name:              "first failure is retried after the base delay",
+			url:               receiver.URL,
+			attempts:          0,
+			expectedStatus:    intPtr(http.StatusServiceUnavailable),
+			expectedNextRetry: timePtr(now.Add(30 * time.Second)),
+		},
+		{
+			name:              "second failure doubles the delay",
+			url:               receiver.URL,
+			attempts:          1,
+			expectedStatus:    intPtr(http.StatusServiceUnavailable),
+			expectedNextRetry: timePtr(now.Add(time.Minute)),
+		},
+		{
+			name:           "last attempt gives up",
+			url:            receiver.URL,
+			attempts:       2,
+			expectedStatus: intPtr(http.StatusServiceUnavailable),
+		},
+		{
+			name:              "unreachable endpoint is retried",
+			url:               "http://127.0.0.1:1",
+			attempts:          0,
+			expectedNextRetry: timePtr(now.Add(30 * time.Second)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := newTestWorker(new(mocks.WebhookStorage), now).deliver(context.Background(), pendingDelivery(tt.url, tt.attempts))
+
+			assert.False(t, attempt.Delivered)
+			assert.Equal(t, tt.expectedStatus, attempt.StatusCode)
+			assert.Equal(t, tt.expectedNextRetry, attempt.NextAttemptAt)
+			assert.NotEmpty(t, attempt.Error)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	w := newTestWorker(new(mocks.WebhookStorage), time.Now())
+
+	assert.Equal(t, 30*time.Second, w.backoff(1))
+	assert.Equal(t, 4*time.Minute, w.backoff(4))
+	assert.Equal(t, time.Hour, w.backoff(20))
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11",
+		Sign("secret", "1700000000", []byte(`{"id":1}`)))
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}